@@ -0,0 +1,158 @@
+// Package alert watches clusters and sends alerts to notifiers when the
+// conditions in a YAML rules file hold, eg.
+//
+//   interval: 1m
+//   clusters: [prod]
+//   rules:
+//     - {name: web-down, type: service-below-desired, service: web, for: 5m}
+//     - {name: crashes, type: task-stopped-nonzero, notify: [ops]}
+//     - {name: agents, type: agent-disconnected, for: 2m}
+//     - {name: memory, type: low-memory, threshold: 512, for: 10m}
+//   notifiers:
+//     - {name: ops, type: slack, url: "https://hooks.slack.com/services/..."}
+//     - {name: console, type: stdout}
+package alert
+
+import (
+  "fmt"
+  "io/ioutil"
+  "os/user"
+  "path/filepath"
+  "time"
+  "github.com/jdrivas/sl"
+  "github.com/Sirupsen/logrus"
+  "gopkg.in/yaml.v2"
+)
+
+const (
+  DefaultInterval = time.Minute
+  defaultConfigFile = ".ecs-pilot/alerts.yaml"
+)
+
+// Rule types.
+const (
+  ServiceBelowDesired = "service-below-desired"
+  TaskStoppedNonZero = "task-stopped-nonzero"
+  AgentDisconnected = "agent-disconnected"
+  LowMemory = "low-memory"
+)
+
+// Notifier types.
+const (
+  StdoutNotifier = "stdout"
+  WebhookNotifier = "webhook"
+  SlackNotifier = "slack"
+  SmtpNotifier = "smtp"
+)
+
+var log = sl.New()
+
+func SetLogLevel(l logrus.Level) {
+  log.SetLevel(l)
+}
+
+func SetLogFormatter(f logrus.Formatter) {
+  log.SetFormatter(f)
+}
+
+type Config struct {
+  Interval time.Duration `yaml:"interval"`
+  Clusters []string `yaml:"clusters"` // All clusters if empty.
+  Rules []*Rule `yaml:"rules"`
+  Notifiers []*NotifierConfig `yaml:"notifiers"`
+}
+
+type Rule struct {
+  Name string `yaml:"name"`
+  Type string `yaml:"type"`
+  Cluster string `yaml:"cluster"` // Any cluster if empty.
+  Service string `yaml:"service"` // Any service if empty, service rules only.
+  For time.Duration `yaml:"for"` // How long the condition holds before alerting.
+  Threshold int64 `yaml:"threshold"` // MB of remaining memory for low-memory.
+  Notify []string `yaml:"notify"` // Notifier names, all of them if empty.
+}
+
+type NotifierConfig struct {
+  Name string `yaml:"name"`
+  Type string `yaml:"type"`
+  URL string `yaml:"url"` // webhook and slack
+  Host string `yaml:"host"` // smtp
+  Port int `yaml:"port"`
+  Username string `yaml:"username"`
+  Password string `yaml:"password"`
+  From string `yaml:"from"`
+  To []string `yaml:"to"`
+}
+
+// What gets sent when a rule starts or stops holding.
+type Alert struct {
+  Rule string `json:"rule"`
+  Type string `json:"type"`
+  Cluster string `json:"cluster"`
+  Subject string `json:"subject"`
+  Message string `json:"message"`
+  Resolved bool `json:"resolved"`
+  Time time.Time `json:"time"`
+}
+
+func (a Alert) String() (string) {
+  state := "ALERT"
+  if a.Resolved { state = "RESOLVED" }
+  return fmt.Sprintf("[%s] %s: %s", state, a.Rule, a.Message)
+}
+
+// ~/.ecs-pilot/alerts.yaml
+func DefaultConfigFile() (string, error) {
+  u, err := user.Current()
+  if err != nil { return "", fmt.Errorf("Can't find the current user's home directory: %s", err) }
+  return filepath.Join(u.HomeDir, defaultConfigFile), nil
+}
+
+func LoadConfig(fileName string) (*Config, error) {
+  b, err := ioutil.ReadFile(fileName)
+  if err != nil { return nil, err }
+  config := new(Config)
+  if err = yaml.Unmarshal(b, config); err != nil { return nil, fmt.Errorf("Can't read alert config %s: %s", fileName, err) }
+  if config.Interval <= 0 { config.Interval = DefaultInterval }
+  if len(config.Notifiers) == 0 {
+    config.Notifiers = []*NotifierConfig{{Name: StdoutNotifier, Type: StdoutNotifier}}
+  }
+  return config, config.validate()
+}
+
+func (c *Config) validate() (error) {
+  notifiers := make(map[string]bool)
+  for _, n := range c.Notifiers {
+    if n.Name == "" { return fmt.Errorf("Notifiers need a name") }
+    if notifiers[n.Name] { return fmt.Errorf("Notifier %s is defined twice", n.Name) }
+    notifiers[n.Name] = true
+    switch n.Type {
+    case StdoutNotifier:
+    case WebhookNotifier, SlackNotifier:
+      if n.URL == "" { return fmt.Errorf("Notifier %s needs a url", n.Name) }
+    case SmtpNotifier:
+      if n.Host == "" || n.From == "" || len(n.To) == 0 { return fmt.Errorf("Notifier %s needs a host, from and to", n.Name) }
+    default:
+      return fmt.Errorf("Notifier %s has unknown type \"%s\"", n.Name, n.Type)
+    }
+  }
+
+  rules := make(map[string]bool)
+  if len(c.Rules) == 0 { return fmt.Errorf("There are no rules") }
+  for _, r := range c.Rules {
+    if r.Name == "" { return fmt.Errorf("Rules need a name") }
+    if rules[r.Name] { return fmt.Errorf("Rule %s is defined twice", r.Name) }
+    rules[r.Name] = true
+    switch r.Type {
+    case ServiceBelowDesired, TaskStoppedNonZero, AgentDisconnected:
+    case LowMemory:
+      if r.Threshold <= 0 { return fmt.Errorf("Rule %s needs a threshold in MB", r.Name) }
+    default:
+      return fmt.Errorf("Rule %s has unknown type \"%s\"", r.Name, r.Type)
+    }
+    for _, n := range r.Notify {
+      if !notifiers[n] { return fmt.Errorf("Rule %s notifies unknown notifier %s", r.Name, n) }
+    }
+  }
+  return nil
+}
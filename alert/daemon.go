@@ -0,0 +1,128 @@
+package alert
+
+import (
+  "os"
+  "os/signal"
+  "syscall"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/Sirupsen/logrus"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+// Checks the clusters every interval until interrupted.
+func Run(config *Config, sess *session.Session) (error) {
+  notifiers := make(map[string]Notifier)
+  for _, nc := range config.Notifiers {
+    n, err := NewNotifier(nc)
+    if err != nil { return err }
+    notifiers[nc.Name] = n
+  }
+  engine := NewEngine(config.Rules, time.Now())
+
+  f := logrus.Fields{"rules": len(config.Rules), "notifiers": len(notifiers), "interval": config.Interval.String()}
+  log.Info(f, "Starting alert watch.")
+
+  stop := make(chan os.Signal, 1)
+  signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+  ticker := time.NewTicker(config.Interval)
+  defer ticker.Stop()
+  for {
+    check(config, engine, notifiers, sess)
+    select {
+    case <-ticker.C:
+    case s := <-stop:
+      log.Info(logrus.Fields{"signal": s.String()}, "Stopping alert watch.")
+      return nil
+    }
+  }
+}
+
+func check(config *Config, engine *Engine, notifiers map[string]Notifier, sess *session.Session) {
+  clusters := config.Clusters
+  if len(clusters) == 0 {
+    names, err := awslib.GetClusters(sess)
+    if err != nil {
+      log.Error(nil, "Can't list clusters.", err)
+      return
+    }
+    clusters = aws.StringValueSlice(names)
+  }
+
+  for _, cluster := range clusters {
+    f := logrus.Fields{"cluster": cluster}
+    state, err := clusterState(cluster, sess)
+    if err != nil {
+      log.Error(f, "Can't get cluster state.", err)
+      continue
+    }
+    alerts := engine.Evaluate(state, time.Now())
+    f["alerts"] = len(alerts)
+    log.Debug(f, "Checked cluster.")
+    for _, a := range alerts {
+      send(a, engine.Rule(a.Rule), notifiers)
+    }
+  }
+}
+
+func send(a Alert, r *Rule, notifiers map[string]Notifier) {
+  names := make([]string, 0)
+  if r != nil { names = r.Notify }
+  if len(names) == 0 {
+    for name := range notifiers { names = append(names, name) }
+  }
+  for _, name := range names {
+    n := notifiers[name]
+    if n == nil { continue }
+    if err := n.Notify(a); err != nil {
+      log.Error(logrus.Fields{"notifier": name, "rule": a.Rule, "cluster": a.Cluster}, "Failed to send alert.", err)
+    }
+  }
+}
+
+func clusterState(clusterName string, sess *session.Session) (*ClusterState, error) {
+  state := &ClusterState{Cluster: clusterName}
+  services, failures, err := awslib.DescribeServices(clusterName, sess)
+  if err != nil { return nil, err }
+  if len(failures) > 0 {
+    log.Debug(logrus.Fields{"cluster": clusterName, "failures": len(failures)}, "Failures describing services.")
+  }
+  state.Services = services
+
+  ciMap, _, err := awslib.GetContainerMaps(clusterName, sess)
+  if err != nil { return nil, err }
+  for _, ci := range ciMap {
+    if ci.Instance != nil { state.Instances = append(state.Instances, ci.Instance) }
+  }
+
+  state.StoppedTasks, err = stoppedTasks(clusterName, sess)
+  return state, err
+}
+
+func stoppedTasks(clusterName string, sess *session.Session) ([]*ecs.Task, error) {
+  ecsSvc := ecs.New(sess)
+  arns := make([]*string, 0)
+  err := ecsSvc.ListTasksPages(&ecs.ListTasksInput{
+    Cluster: aws.String(clusterName),
+    DesiredStatus: aws.String(ecs.DesiredStatusStopped),
+  }, func(page *ecs.ListTasksOutput, last bool) bool {
+    arns = append(arns, page.TaskArns...)
+    return true
+  })
+  if err != nil { return nil, err }
+
+  // DescribeTasks takes at most 100 tasks at a time.
+  tasks := make([]*ecs.Task, 0, len(arns))
+  for i := 0; i < len(arns); i += 100 {
+    end := i + 100
+    if end > len(arns) { end = len(arns) }
+    resp, err := ecsSvc.DescribeTasks(&ecs.DescribeTasksInput{Cluster: aws.String(clusterName), Tasks: arns[i:end]})
+    if err != nil { return nil, err }
+    tasks = append(tasks, resp.Tasks...)
+  }
+  return tasks, nil
+}
@@ -0,0 +1,97 @@
+package alert
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "net/smtp"
+  "strings"
+  "time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+type Notifier interface {
+  Name() (string)
+  Notify(a Alert) (error)
+}
+
+func NewNotifier(c *NotifierConfig) (Notifier, error) {
+  switch c.Type {
+  case StdoutNotifier: return &stdoutNotifier{name: c.Name}, nil
+  case WebhookNotifier: return &webhookNotifier{name: c.Name, url: c.URL}, nil
+  case SlackNotifier: return &slackNotifier{name: c.Name, url: c.URL}, nil
+  case SmtpNotifier: return &smtpNotifier{config: *c}, nil
+  }
+  return nil, fmt.Errorf("Unknown notifier type \"%s\"", c.Type)
+}
+
+type stdoutNotifier struct {
+  name string
+}
+
+func (n *stdoutNotifier) Name() (string) { return n.name }
+
+func (n *stdoutNotifier) Notify(a Alert) (error) {
+  fmt.Printf("%s %s\n", a.Time.Local().Format(time.RFC1123), a)
+  return nil
+}
+
+// Posts the alert as JSON.
+type webhookNotifier struct {
+  name string
+  url string
+}
+
+func (n *webhookNotifier) Name() (string) { return n.name }
+
+func (n *webhookNotifier) Notify(a Alert) (error) {
+  return postJSON(n.url, a)
+}
+
+// Slack incoming webhooks, and the many services that take the same payload.
+type slackNotifier struct {
+  name string
+  url string
+}
+
+func (n *slackNotifier) Name() (string) { return n.name }
+
+func (n *slackNotifier) Notify(a Alert) (error) {
+  return postJSON(n.url, map[string]string{"text": a.String()})
+}
+
+func postJSON(url string, v interface{}) (error) {
+  b, err := json.Marshal(v)
+  if err != nil { return err }
+  client := &http.Client{Timeout: notifyTimeout}
+  resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+  if err != nil { return err }
+  defer resp.Body.Close()
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return fmt.Errorf("POST to %s returned %s", url, resp.Status)
+  }
+  return nil
+}
+
+type smtpNotifier struct {
+  config NotifierConfig
+}
+
+func (n *smtpNotifier) Name() (string) { return n.config.Name }
+
+func (n *smtpNotifier) Notify(a Alert) (error) {
+  c := n.config
+  port := c.Port
+  if port == 0 { port = 25 }
+  addr := fmt.Sprintf("%s:%d", c.Host, port)
+  var auth smtp.Auth
+  if c.Username != "" { auth = smtp.PlainAuth("", c.Username, c.Password, c.Host) }
+
+  subject := fmt.Sprintf("[ecs-pilot] %s", a)
+  if len(subject) > 120 { subject = subject[:117] + "..." }
+  msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n\r\nCluster: %s\r\nSubject: %s\r\nTime: %s\r\n",
+    c.From, strings.Join(c.To, ", "), subject, a.Message, a.Cluster, a.Subject, a.Time.Format(time.RFC1123))
+  return smtp.SendMail(addr, auth, c.From, c.To, []byte(msg))
+}
@@ -0,0 +1,181 @@
+package alert
+
+import (
+  "fmt"
+  "strings"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// What the rules are evaluated against, one cluster at a time.
+type ClusterState struct {
+  Cluster string
+  Services []*ecs.Service
+  Instances []*ecs.ContainerInstance
+  StoppedTasks []*ecs.Task
+}
+
+// A rule holding for a subject, eg. a service or instance.
+type condition struct {
+  rule *Rule
+  cluster string
+  subject string
+  message string
+}
+
+func (c condition) key() (string) {
+  return strings.Join([]string{c.rule.Name, c.cluster, c.subject}, "|")
+}
+
+// Keeps track of how long conditions have held and which have alerted,
+// so we alert once when a condition has held for long enough and once when it clears.
+type Engine struct {
+  rules []*Rule
+  started time.Time
+  pending map[string]time.Time
+  firing map[string]condition
+  seenStopped map[string]map[string]bool // rule|cluster -> task arn
+}
+
+func NewEngine(rules []*Rule, started time.Time) (*Engine) {
+  return &Engine{
+    rules: rules,
+    started: started,
+    pending: make(map[string]time.Time),
+    firing: make(map[string]condition),
+    seenStopped: make(map[string]map[string]bool),
+  }
+}
+
+func (e *Engine) Rule(name string) (*Rule) {
+  for _, r := range e.rules {
+    if r.Name == name { return r }
+  }
+  return nil
+}
+
+func (e *Engine) Evaluate(state *ClusterState, now time.Time) ([]Alert) {
+  alerts := make([]Alert, 0)
+  active := make(map[string]bool)
+  for _, r := range e.rules {
+    if r.Cluster != "" && r.Cluster != state.Cluster { continue }
+    if r.Type == TaskStoppedNonZero {
+      alerts = append(alerts, e.stoppedTaskAlerts(r, state, now)...)
+      continue
+    }
+    for _, c := range conditions(r, state) {
+      k := c.key()
+      active[k] = true
+      if _, ok := e.firing[k]; ok { continue }
+      first, ok := e.pending[k]
+      if !ok {
+        first = now
+        e.pending[k] = now
+      }
+      if now.Sub(first) >= r.For {
+        delete(e.pending, k)
+        e.firing[k] = c
+        alerts = append(alerts, c.alert(false, now))
+      }
+    }
+  }
+
+  for k := range e.pending {
+    if keyCluster(k) == state.Cluster && !active[k] { delete(e.pending, k) }
+  }
+  for k, c := range e.firing {
+    if c.cluster == state.Cluster && !active[k] {
+      delete(e.firing, k)
+      alerts = append(alerts, c.alert(true, now))
+    }
+  }
+  return alerts
+}
+
+func keyCluster(k string) (string) {
+  parts := strings.SplitN(k, "|", 3)
+  if len(parts) < 2 { return "" }
+  return parts[1]
+}
+
+func (c condition) alert(resolved bool, now time.Time) (Alert) {
+  return Alert{Rule: c.rule.Name, Type: c.rule.Type, Cluster: c.cluster, Subject: c.subject,
+    Message: c.message, Resolved: resolved, Time: now}
+}
+
+func conditions(r *Rule, state *ClusterState) ([]condition) {
+  cs := make([]condition, 0)
+  switch r.Type {
+  case ServiceBelowDesired:
+    for _, s := range state.Services {
+      name := aws.StringValue(s.ServiceName)
+      if r.Service != "" && r.Service != name { continue }
+      if aws.StringValue(s.Status) != "ACTIVE" { continue }
+      running, desired := aws.Int64Value(s.RunningCount), aws.Int64Value(s.DesiredCount)
+      if running < desired {
+        cs = append(cs, condition{rule: r, cluster: state.Cluster, subject: name,
+          message: fmt.Sprintf("Service %s on cluster %s has %d of %d tasks running.", name, state.Cluster, running, desired)})
+      }
+    }
+  case AgentDisconnected:
+    for _, ci := range state.Instances {
+      if aws.StringValue(ci.Status) == ecs.ContainerInstanceStatusInactive { continue }
+      if !aws.BoolValue(ci.AgentConnected) {
+        id := aws.StringValue(ci.Ec2InstanceId)
+        cs = append(cs, condition{rule: r, cluster: state.Cluster, subject: id,
+          message: fmt.Sprintf("The ECS agent on %s in cluster %s is disconnected.", id, state.Cluster)})
+      }
+    }
+  case LowMemory:
+    for _, ci := range state.Instances {
+      if aws.StringValue(ci.Status) != ecs.ContainerInstanceStatusActive { continue }
+      mem := remainingMemory(ci)
+      if mem < r.Threshold {
+        id := aws.StringValue(ci.Ec2InstanceId)
+        cs = append(cs, condition{rule: r, cluster: state.Cluster, subject: id,
+          message: fmt.Sprintf("Instance %s in cluster %s has %dMB of memory left, under %dMB.", id, state.Cluster, mem, r.Threshold)})
+      }
+    }
+  }
+  return cs
+}
+
+func remainingMemory(ci *ecs.ContainerInstance) (int64) {
+  for _, r := range ci.RemainingResources {
+    if aws.StringValue(r.Name) == "MEMORY" { return aws.Int64Value(r.IntegerValue) }
+  }
+  return 0
+}
+
+// Stopped tasks are events rather than conditions, each alerts once and never resolves.
+// Tasks stopped before we started watching are ignored.
+func (e *Engine) stoppedTaskAlerts(r *Rule, state *ClusterState, now time.Time) ([]Alert) {
+  alerts := make([]Alert, 0)
+  seenKey := r.Name + "|" + state.Cluster
+  seen := e.seenStopped[seenKey]
+  current := make(map[string]bool)
+  for _, t := range state.StoppedTasks {
+    arn := aws.StringValue(t.TaskArn)
+    current[arn] = true
+    if seen[arn] || (t.StoppedAt != nil && t.StoppedAt.Before(e.started)) { continue }
+    if r.Service != "" && aws.StringValue(t.Group) != "service:" + r.Service { continue }
+    for _, c := range t.Containers {
+      if c.ExitCode == nil || *c.ExitCode == 0 { continue }
+      a := Alert{Rule: r.Name, Type: r.Type, Cluster: state.Cluster, Subject: arn, Time: now,
+        Message: fmt.Sprintf("Task %s (%s) on cluster %s stopped: container %s exited %d. %s",
+          shortArn(arn), aws.StringValue(t.Group), state.Cluster, aws.StringValue(c.Name), *c.ExitCode,
+          aws.StringValue(t.StoppedReason))}
+      alerts = append(alerts, a)
+      break
+    }
+  }
+  // ECS only keeps stopped tasks for a while, so only remember what it still has.
+  e.seenStopped[seenKey] = current
+  return alerts
+}
+
+func shortArn(arn string) (string) {
+  if i := strings.LastIndex(arn, "/"); i >= 0 { return arn[i+1:] }
+  return arn
+}
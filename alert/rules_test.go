@@ -0,0 +1,96 @@
+package alert
+
+import(
+  "testing"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func testService(name string, running, desired int64) (*ecs.Service) {
+  return &ecs.Service{ServiceName: aws.String(name), Status: aws.String("ACTIVE"),
+    RunningCount: aws.Int64(running), DesiredCount: aws.Int64(desired)}
+}
+
+func TestServiceBelowDesiredWaitsAndResolves(t *testing.T) {
+  rule := &Rule{Name: "web-down", Type: ServiceBelowDesired, For: 5 * time.Minute}
+  start := time.Now()
+  e := NewEngine([]*Rule{rule}, start)
+
+  state := &ClusterState{Cluster: "prod", Services: []*ecs.Service{testService("web", 1, 3), testService("api", 2, 2)}}
+  assert.Empty(t, e.Evaluate(state, start), "Shouldn't alert before the condition has held long enough.")
+  assert.Empty(t, e.Evaluate(state, start.Add(4 * time.Minute)))
+
+  alerts := e.Evaluate(state, start.Add(5 * time.Minute))
+  if assert.Len(t, alerts, 1) {
+    assert.Equal(t, "web", alerts[0].Subject)
+    assert.False(t, alerts[0].Resolved)
+  }
+  assert.Empty(t, e.Evaluate(state, start.Add(6 * time.Minute)), "Should only alert once.")
+
+  state.Services[0] = testService("web", 3, 3)
+  alerts = e.Evaluate(state, start.Add(7 * time.Minute))
+  if assert.Len(t, alerts, 1) {
+    assert.True(t, alerts[0].Resolved)
+  }
+}
+
+func TestConditionClearingResetsTheWait(t *testing.T) {
+  rule := &Rule{Name: "web-down", Type: ServiceBelowDesired, For: 5 * time.Minute}
+  start := time.Now()
+  e := NewEngine([]*Rule{rule}, start)
+  down := &ClusterState{Cluster: "prod", Services: []*ecs.Service{testService("web", 1, 3)}}
+  up := &ClusterState{Cluster: "prod", Services: []*ecs.Service{testService("web", 3, 3)}}
+
+  e.Evaluate(down, start)
+  e.Evaluate(up, start.Add(3 * time.Minute))
+  assert.Empty(t, e.Evaluate(down, start.Add(6 * time.Minute)), "The wait should start over after clearing.")
+  assert.Len(t, e.Evaluate(down, start.Add(11 * time.Minute)), 1)
+}
+
+func TestAgentAndMemoryRules(t *testing.T) {
+  rules := []*Rule{
+    {Name: "agent", Type: AgentDisconnected},
+    {Name: "memory", Type: LowMemory, Threshold: 512, Cluster: "prod"},
+  }
+  e := NewEngine(rules, time.Now())
+  state := &ClusterState{Cluster: "prod", Instances: []*ecs.ContainerInstance{
+    {Ec2InstanceId: aws.String("i-1"), Status: aws.String("ACTIVE"), AgentConnected: aws.Bool(false),
+      RemainingResources: []*ecs.Resource{{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(2048)}}},
+    {Ec2InstanceId: aws.String("i-2"), Status: aws.String("ACTIVE"), AgentConnected: aws.Bool(true),
+      RemainingResources: []*ecs.Resource{{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(256)}}},
+  }}
+  alerts := e.Evaluate(state, time.Now())
+  if assert.Len(t, alerts, 2) {
+    assert.Equal(t, "agent", alerts[0].Rule)
+    assert.Equal(t, "i-1", alerts[0].Subject)
+    assert.Equal(t, "memory", alerts[1].Rule)
+    assert.Equal(t, "i-2", alerts[1].Subject)
+  }
+
+  state.Cluster = "staging"
+  alerts = e.Evaluate(state, time.Now())
+  if assert.Len(t, alerts, 1, "The memory rule is only for prod.") {
+    assert.Equal(t, "agent", alerts[0].Rule)
+  }
+}
+
+func TestStoppedTaskAlertsOnce(t *testing.T) {
+  start := time.Now()
+  e := NewEngine([]*Rule{{Name: "crash", Type: TaskStoppedNonZero}}, start)
+  task := func(arn string, exit int64, stopped time.Time) (*ecs.Task) {
+    return &ecs.Task{TaskArn: aws.String(arn), StoppedAt: aws.Time(stopped), Group: aws.String("service:web"),
+      Containers: []*ecs.Container{{Name: aws.String("web"), ExitCode: aws.Int64(exit)}}}
+  }
+  state := &ClusterState{Cluster: "prod", StoppedTasks: []*ecs.Task{
+    task("arn:task/old", 1, start.Add(-time.Minute)),
+    task("arn:task/clean", 0, start.Add(time.Minute)),
+    task("arn:task/crashed", 137, start.Add(time.Minute)),
+  }}
+  alerts := e.Evaluate(state, start.Add(2 * time.Minute))
+  if assert.Len(t, alerts, 1) {
+    assert.Equal(t, "arn:task/crashed", alerts[0].Subject)
+  }
+  assert.Empty(t, e.Evaluate(state, start.Add(3 * time.Minute)))
+}
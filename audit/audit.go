@@ -0,0 +1,134 @@
+// Package audit keeps an append-only record of the changes made through ecs-pilot,
+// one JSON object a line, and sends it on to any other sinks configured, eg.
+//
+//   audit:
+//     - {type: file, path: /var/log/ecs-pilot/audit.log}
+//     - {type: syslog, tag: ecs-pilot}
+//     - {type: webhook, url: "https://audit.example.com/ecs-pilot"}
+//
+// There's always a file sink, ~/.ecs-pilot/audit.log unless another path is given.
+package audit
+
+import (
+  "bufio"
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "os"
+  "os/user"
+  "time"
+)
+
+// Results.
+const (
+  ResultOk = "ok"
+  ResultFailed = "failed"
+  ResultDeclined = "declined" // Not confirmed, so nothing was done.
+)
+
+// Sink types.
+const (
+  FileSink = "file"
+  SyslogSink = "syslog"
+  WebhookSink = "webhook"
+)
+
+// One change, or attempt at one.
+type Entry struct {
+  Time time.Time `json:"time"`
+  User string `json:"user"` // Local user name, or the JWT subject for the server.
+  Identity string `json:"identity,omitempty"` // The AWS caller ARN.
+  Cluster string `json:"cluster,omitempty"`
+  Command string `json:"command"`
+  Arguments []string `json:"arguments,omitempty"`
+  Result string `json:"result"`
+  Error string `json:"error,omitempty"`
+}
+
+type SinkConfig struct {
+  Type string `yaml:"type"`
+  Path string `yaml:"path"` // file
+  Tag string `yaml:"tag"` // syslog
+  URL string `yaml:"url"` // webhook
+}
+
+type Sink interface {
+  Name() (string)
+  Write(e Entry) (error)
+}
+
+func NewSink(c *SinkConfig) (Sink, error) {
+  switch c.Type {
+  case FileSink:
+    if c.Path == "" { return nil, fmt.Errorf("The audit file sink needs a path") }
+    return &fileSink{path: c.Path}, nil
+  case SyslogSink: return newSyslogSink(c.Tag)
+  case WebhookSink:
+    if c.URL == "" { return nil, fmt.Errorf("The audit webhook sink needs a url") }
+    return &webhookSink{url: c.URL}, nil
+  }
+  return nil, fmt.Errorf("Unknown audit sink type \"%s\"", c.Type)
+}
+
+// Writes entries to every sink.
+type Log struct {
+  FilePath string // The local file, for reading back.
+  sinks []Sink
+}
+
+// A file sink at defaultPath is added if there's no file sink in configs.
+func New(configs []*SinkConfig, defaultPath string) (*Log, error) {
+  l := &Log{}
+  for _, c := range configs {
+    s, err := NewSink(c)
+    if err != nil { return nil, err }
+    if c.Type == FileSink && l.FilePath == "" { l.FilePath = c.Path }
+    l.sinks = append(l.sinks, s)
+  }
+  if l.FilePath == "" {
+    l.FilePath = defaultPath
+    l.sinks = append([]Sink{&fileSink{path: defaultPath}}, l.sinks...)
+  }
+  return l, nil
+}
+
+// Every sink gets the entry even if one fails, the error names those that did.
+func (l *Log) Record(e Entry) (error) {
+  if e.Time.IsZero() { e.Time = time.Now() }
+  var failed bytes.Buffer
+  for _, s := range l.sinks {
+    if err := s.Write(e); err != nil {
+      if failed.Len() > 0 { failed.WriteString("; ") }
+      fmt.Fprintf(&failed, "%s: %s", s.Name(), err)
+    }
+  }
+  if failed.Len() > 0 { return fmt.Errorf("Audit sinks failed: %s", failed.String()) }
+  return nil
+}
+
+// The entries in an audit file, oldest first. No file is no entries.
+func ReadFile(path string) ([]Entry, error) {
+  f, err := os.Open(path)
+  if os.IsNotExist(err) { return []Entry{}, nil }
+  if err != nil { return nil, err }
+  defer f.Close()
+
+  entries := make([]Entry, 0)
+  scanner := bufio.NewScanner(f)
+  scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+  for n := 1; scanner.Scan(); n++ {
+    var e Entry
+    if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+      return entries, fmt.Errorf("Bad audit entry at %s:%d: %s", path, n, err)
+    }
+    entries = append(entries, e)
+  }
+  return entries, scanner.Err()
+}
+
+// The user running ecs-pilot, for entries made from the command line.
+func LocalUser() (string) {
+  if u, err := user.Current(); err == nil { return u.Username }
+  if name := os.Getenv("USER"); name != "" { return name }
+  return "unknown"
+}
@@ -0,0 +1,73 @@
+package audit
+
+import(
+  "errors"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+type failingSink struct{}
+
+func (s *failingSink) Name() (string) { return "failing" }
+func (s *failingSink) Write(e Entry) (error) { return errors.New("down") }
+
+func TestFileSinkRoundTrip(t *testing.T) {
+  dir, err := ioutil.TempDir("", "audit")
+  if !assert.NoError(t, err) { return }
+  defer os.RemoveAll(dir)
+  fn := filepath.Join(dir, "audit.log")
+
+  l, err := New(nil, fn)
+  if !assert.NoError(t, err) { return }
+  assert.Equal(t, fn, l.FilePath, "Should default to a file sink.")
+
+  assert.NoError(t, l.Record(Entry{User: "pat", Cluster: "prod", Command: "service delete",
+    Arguments: []string{"web"}, Result: ResultOk}))
+  assert.NoError(t, l.Record(Entry{User: "pat", Cluster: "prod", Command: "task stop",
+    Arguments: []string{"abc"}, Result: ResultFailed, Error: "no such task"}))
+
+  entries, err := ReadFile(fn)
+  assert.NoError(t, err)
+  if assert.Len(t, entries, 2) {
+    assert.Equal(t, "service delete", entries[0].Command)
+    assert.Equal(t, []string{"web"}, entries[0].Arguments)
+    assert.False(t, entries[0].Time.IsZero())
+    assert.Equal(t, "no such task", entries[1].Error)
+  }
+
+  info, err := os.Stat(fn)
+  if assert.NoError(t, err) { assert.Equal(t, os.FileMode(0600), info.Mode().Perm()) }
+
+  entries, err = ReadFile(filepath.Join(dir, "none"))
+  assert.NoError(t, err)
+  assert.Empty(t, entries)
+}
+
+func TestRecordReachesEverySink(t *testing.T) {
+  dir, err := ioutil.TempDir("", "audit")
+  if !assert.NoError(t, err) { return }
+  defer os.RemoveAll(dir)
+  fn := filepath.Join(dir, "audit.log")
+
+  l := &Log{FilePath: fn, sinks: []Sink{&failingSink{}, &fileSink{path: fn}}}
+  err = l.Record(Entry{Command: "cluster delete", Result: ResultOk})
+  if assert.Error(t, err) { assert.Contains(t, err.Error(), "failing: down") }
+  entries, _ := ReadFile(fn)
+  assert.Len(t, entries, 1, "A failing sink shouldn't stop the others.")
+}
+
+func TestNewSinkConfig(t *testing.T) {
+  _, err := NewSink(&SinkConfig{Type: "carrier-pigeon"})
+  assert.Error(t, err)
+  _, err = NewSink(&SinkConfig{Type: WebhookSink})
+  assert.Error(t, err, "A webhook needs a url.")
+
+  l, err := New([]*SinkConfig{{Type: FileSink, Path: "/tmp/elsewhere.log"}}, "/tmp/default.log")
+  if assert.NoError(t, err) {
+    assert.Equal(t, "/tmp/elsewhere.log", l.FilePath)
+    assert.Len(t, l.sinks, 1)
+  }
+}
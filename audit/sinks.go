@@ -0,0 +1,51 @@
+package audit
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "os"
+  "path/filepath"
+  "time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Appends JSON lines, only the user can read them.
+type fileSink struct {
+  path string
+}
+
+func (s *fileSink) Name() (string) { return FileSink + " " + s.path }
+
+func (s *fileSink) Write(e Entry) (error) {
+  b, err := json.Marshal(e)
+  if err != nil { return err }
+  if err = os.MkdirAll(filepath.Dir(s.path), 0700); err != nil { return err }
+  f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+  if err != nil { return err }
+  _, err = f.Write(append(b, '\n'))
+  if cerr := f.Close(); err == nil { err = cerr }
+  return err
+}
+
+// Posts each entry as JSON.
+type webhookSink struct {
+  url string
+}
+
+func (s *webhookSink) Name() (string) { return WebhookSink + " " + s.url }
+
+func (s *webhookSink) Write(e Entry) (error) {
+  b, err := json.Marshal(e)
+  if err != nil { return err }
+  client := &http.Client{Timeout: webhookTimeout}
+  resp, err := client.Post(s.url, "application/json", bytes.NewReader(b))
+  if err != nil { return err }
+  defer resp.Body.Close()
+  if resp.StatusCode < 200 || resp.StatusCode > 299 {
+    return fmt.Errorf("POST to %s returned %s", s.url, resp.Status)
+  }
+  return nil
+}
@@ -0,0 +1,30 @@
+// +build !windows,!plan9
+
+package audit
+
+import (
+  "encoding/json"
+  "log/syslog"
+)
+
+const defaultSyslogTag = "ecs-pilot"
+
+// Sends each entry as JSON to the local syslog.
+type syslogSink struct {
+  w *syslog.Writer
+}
+
+func newSyslogSink(tag string) (Sink, error) {
+  if tag == "" { tag = defaultSyslogTag }
+  w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_USER, tag)
+  if err != nil { return nil, err }
+  return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Name() (string) { return SyslogSink }
+
+func (s *syslogSink) Write(e Entry) (error) {
+  b, err := json.Marshal(e)
+  if err != nil { return err }
+  return s.w.Notice(string(b))
+}
@@ -0,0 +1,9 @@
+// +build windows plan9
+
+package audit
+
+import "fmt"
+
+func newSyslogSink(tag string) (Sink, error) {
+  return nil, fmt.Errorf("There's no syslog on this platform, use a file or webhook audit sink")
+}
@@ -0,0 +1,175 @@
+// Package doctor checks that the AWS identity ecs-pilot runs as can do what
+// ecs-pilot asks of it, before an AccessDenied turns up halfway through a command.
+package doctor
+
+import (
+  "fmt"
+  "sort"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/iam"
+  "github.com/aws/aws-sdk-go/service/sts"
+)
+
+// Permission levels, each includes the ones before it.
+const (
+  ReadLevel = "read"
+  DeployLevel = "deploy"
+  AdminLevel = "admin"
+)
+
+var Levels = []string{ReadLevel, DeployLevel, AdminLevel}
+
+// Something ecs-pilot does and the actions it calls to do it.
+type Feature struct {
+  Name string `json:"name"`
+  Level string `json:"level"`
+  Actions []string `json:"actions"`
+}
+
+// The actions ecs-pilot calls, and the PassRole launching an instance with a profile needs.
+var Features = []Feature{
+  {"clusters", ReadLevel, []string{"ecs:ListClusters", "ecs:DescribeClusters"}},
+  {"services", ReadLevel, []string{"ecs:ListServices", "ecs:DescribeServices"}},
+  {"tasks", ReadLevel, []string{"ecs:ListTasks", "ecs:DescribeTasks", "ecs:ListTaskDefinitions",
+    "ecs:ListTaskDefinitionFamilies", "ecs:DescribeTaskDefinition"}},
+  {"instances", ReadLevel, []string{"ecs:ListContainerInstances", "ecs:DescribeContainerInstances",
+    "ec2:DescribeInstances", "ec2:DescribeSecurityGroups", "ec2:DescribeNetworkInterfaces"}},
+  {"images", ReadLevel, []string{"ecr:DescribeRepositories", "ecr:ListImages", "ecr:DescribeImages"}},
+  {"task logs", ReadLevel, []string{"logs:GetLogEvents"}},
+  {"identity", ReadLevel, []string{"sts:GetCallerIdentity"}},
+  {"server roles from JWTs", ReadLevel, []string{"sts:AssumeRole"}},
+  {"utilization metrics", ReadLevel, []string{"cloudwatch:GetMetricData"}},
+  {"capacity providers", ReadLevel, []string{"ecs:DescribeCapacityProviders", "autoscaling:DescribeAutoScalingGroups"}},
+
+  {"register task definitions", DeployLevel, []string{"ecs:RegisterTaskDefinition"}},
+  {"create and update services", DeployLevel, []string{"ecs:CreateService", "ecs:UpdateService"}},
+  {"run and stop tasks", DeployLevel, []string{"ecs:RunTask", "ecs:StopTask"}},
+  {"blue/green deploys", DeployLevel, []string{"codedeploy:CreateDeployment", "codedeploy:StopDeployment",
+    "codedeploy:GetDeployment", "codedeploy:ListDeployments", "codedeploy:ListApplications",
+    "codedeploy:ListDeploymentGroups", "codedeploy:BatchGetDeploymentGroups"}},
+  {"service auto scaling", DeployLevel, []string{"application-autoscaling:RegisterScalableTarget",
+    "application-autoscaling:PutScalingPolicy", "application-autoscaling:DescribeScalableTargets",
+    "application-autoscaling:DescribeScalingPolicies", "application-autoscaling:DeregisterScalableTarget",
+    "application-autoscaling:DeleteScalingPolicy"}},
+  {"drain instances", DeployLevel, []string{"ecs:UpdateContainerInstancesState"}},
+
+  {"create and delete clusters", AdminLevel, []string{"ecs:CreateCluster", "ecs:DeleteCluster"}},
+  {"delete services", AdminLevel, []string{"ecs:DeleteService"}},
+  {"launch instances", AdminLevel, []string{"ec2:RunInstances", "ec2:CreateTags",
+    "ec2:DescribeInstanceAttribute", "iam:PassRole", "ssm:GetParameter"}},
+  {"roll instances", AdminLevel, []string{"ec2:RunInstances", "ec2:TerminateInstances",
+    "ec2:DescribeInstanceAttribute", "iam:PassRole", "ssm:GetParameter", "ecs:UpdateContainerInstancesState", "ecs:DeregisterContainerInstance"}},
+  {"set capacity", AdminLevel, []string{"ecs:UpdateCapacityProvider", "autoscaling:UpdateAutoScalingGroup",
+    "autoscaling:SetInstanceProtection"}},
+  {"terminate and drain instances", AdminLevel, []string{"ec2:TerminateInstances",
+    "ecs:DeregisterContainerInstance", "ecs:UpdateContainerInstancesState"}},
+}
+
+// A feature and the actions it's missing, none if it's good to go.
+type Result struct {
+  Feature
+  Missing []string `json:"missing"`
+}
+
+func (r Result) Ok() (bool) { return len(r.Missing) == 0 }
+
+// The features needed at level, which includes the levels below it.
+func FeaturesFor(level string) ([]Feature, error) {
+  rank := levelRank(level)
+  if rank < 0 { return nil, fmt.Errorf("Unknown permission level \"%s\", use one of: %s", level, strings.Join(Levels, ", ")) }
+  features := make([]Feature, 0, len(Features))
+  for _, f := range Features {
+    if levelRank(f.Level) <= rank { features = append(features, f) }
+  }
+  return features, nil
+}
+
+func levelRank(level string) (int) {
+  for i, l := range Levels {
+    if l == level { return i }
+  }
+  return -1
+}
+
+// The IAM principal to simulate for a caller ARN from GetCallerIdentity. An assumed
+// role session becomes its role, without any path the role has, see roleWithPath.
+func PrincipalArn(callerArn string) (string, error) {
+  parts := strings.SplitN(callerArn, ":", 6)
+  if len(parts) != 6 || parts[0] != "arn" { return "", fmt.Errorf("Not an ARN: %s", callerArn) }
+  partition, service, account, resource := parts[1], parts[2], parts[4], parts[5]
+  switch {
+  case service == "iam" && resource == "root":
+    return "", fmt.Errorf("%s is the account's root user, which can do anything, but shouldn't be used", callerArn)
+  case service == "iam" && strings.HasPrefix(resource, "user/"):
+    return callerArn, nil
+  case service == "iam" && strings.HasPrefix(resource, "role/"):
+    return callerArn, nil
+  case service == "sts" && strings.HasPrefix(resource, "assumed-role/"):
+    names := strings.Split(resource, "/")
+    if len(names) < 2 || names[1] == "" { return "", fmt.Errorf("No role name in %s", callerArn) }
+    return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, account, names[1]), nil
+  }
+  return "", fmt.Errorf("Can't simulate policies for %s, only IAM users and roles", callerArn)
+}
+
+// The role ARN, with its path, if the role can be read.
+func roleWithPath(roleArn string, sess *session.Session) (string) {
+  i := strings.LastIndex(roleArn, "/")
+  if !strings.Contains(roleArn, ":role/") || i < 0 { return roleArn }
+  resp, err := iam.New(sess).GetRole(&iam.GetRoleInput{RoleName: aws.String(roleArn[i+1:])})
+  if err != nil || resp.Role == nil || resp.Role.Arn == nil { return roleArn }
+  return *resp.Role.Arn
+}
+
+// Simulates the policies of the identity sess runs as against the actions of the
+// features at level. With a session from a JWT's assumed role that's the role.
+func CheckPermissions(level string, sess *session.Session) (string, []Result, error) {
+  features, err := FeaturesFor(level)
+  if err != nil { return "", nil, err }
+
+  id, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+  if err != nil { return "", nil, fmt.Errorf("Can't find the current identity: %s", err) }
+  principal, err := PrincipalArn(aws.StringValue(id.Arn))
+  if err != nil { return "", nil, err }
+  principal = roleWithPath(principal, sess)
+
+  decisions := make(map[string]string)
+  input := &iam.SimulatePrincipalPolicyInput{PolicySourceArn: aws.String(principal), ActionNames: aws.StringSlice(actionNames(features))}
+  err = iam.New(sess).SimulatePrincipalPolicyPages(input, func(page *iam.SimulatePolicyResponse, last bool) (bool) {
+    for _, r := range page.EvaluationResults {
+      decisions[aws.StringValue(r.EvalActionName)] = aws.StringValue(r.EvalDecision)
+    }
+    return true
+  })
+  if err != nil { return principal, nil, fmt.Errorf("Can't simulate the policies of %s (this needs iam:SimulatePrincipalPolicy): %s", principal, err) }
+  return principal, results(features, decisions), nil
+}
+
+// Every action once, sorted.
+func actionNames(features []Feature) ([]string) {
+  seen := make(map[string]bool)
+  names := make([]string, 0)
+  for _, f := range features {
+    for _, a := range f.Actions {
+      if !seen[a] { names = append(names, a) }
+      seen[a] = true
+    }
+  }
+  sort.Strings(names)
+  return names
+}
+
+// Anything not allowed is missing, including actions the simulation didn't answer for.
+func results(features []Feature, decisions map[string]string) ([]Result) {
+  rs := make([]Result, 0, len(features))
+  for _, f := range features {
+    r := Result{Feature: f, Missing: []string{}}
+    for _, a := range f.Actions {
+      if decisions[a] != iam.PolicyEvaluationDecisionTypeAllowed { r.Missing = append(r.Missing, a) }
+    }
+    rs = append(rs, r)
+  }
+  return rs
+}
@@ -0,0 +1,56 @@
+package doctor
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestFeaturesFor(t *testing.T) {
+  read, err := FeaturesFor(ReadLevel)
+  assert.NoError(t, err)
+  deploy, _ := FeaturesFor(DeployLevel)
+  admin, _ := FeaturesFor(AdminLevel)
+  assert.True(t, len(read) < len(deploy) && len(deploy) < len(admin))
+  assert.Len(t, admin, len(Features))
+  for _, f := range read { assert.Equal(t, ReadLevel, f.Level) }
+
+  _, err = FeaturesFor("superuser")
+  assert.Error(t, err)
+}
+
+func TestPrincipalArn(t *testing.T) {
+  tests := []struct {
+    caller string
+    principal string
+  }{
+    {"arn:aws:sts::123456789012:assumed-role/ecs-pilot/session-1", "arn:aws:iam::123456789012:role/ecs-pilot"},
+    {"arn:aws-cn:sts::123456789012:assumed-role/deployer/ECS_PILOT_TEST_SESSION", "arn:aws-cn:iam::123456789012:role/deployer"},
+    {"arn:aws:iam::123456789012:user/pat", "arn:aws:iam::123456789012:user/pat"},
+    {"arn:aws:iam::123456789012:role/ops/ecs-pilot", "arn:aws:iam::123456789012:role/ops/ecs-pilot"},
+  }
+  for _, test := range tests {
+    principal, err := PrincipalArn(test.caller)
+    assert.NoError(t, err, test.caller)
+    assert.Equal(t, test.principal, principal)
+  }
+  for _, caller := range []string{"arn:aws:iam::123456789012:root", "arn:aws:sts::123456789012:federated-user/pat", "pat"} {
+    _, err := PrincipalArn(caller)
+    assert.Error(t, err, caller)
+  }
+}
+
+func TestResults(t *testing.T) {
+  features := []Feature{
+    {"services", ReadLevel, []string{"ecs:ListServices", "ecs:DescribeServices"}},
+    {"run and stop tasks", DeployLevel, []string{"ecs:RunTask", "ecs:StopTask"}},
+  }
+  assert.Equal(t, []string{"ecs:DescribeServices", "ecs:ListServices", "ecs:RunTask", "ecs:StopTask"}, actionNames(features))
+
+  decisions := map[string]string{"ecs:ListServices": "allowed", "ecs:DescribeServices": "allowed",
+    "ecs:RunTask": "implicitDeny"}
+  rs := results(features, decisions)
+  if assert.Len(t, rs, 2) {
+    assert.True(t, rs[0].Ok())
+    assert.Equal(t, []string{"ecs:RunTask", "ecs:StopTask"}, rs[1].Missing, "Unanswered actions are missing too.")
+  }
+}
@@ -5,6 +5,7 @@ import (
   "encoding/json"
   "fmt"
   "os"
+  "ecs-pilot/alert"
   "ecs-pilot/interactive"
   "ecs-pilot/version"
   "github.com/alecthomas/kingpin"
@@ -49,6 +50,24 @@ var(
   emptyTaskDefinition *kingpin.CmdClause
   defaultTaskDefinition *kingpin.CmdClause
   taskDefinitionArn string
+
+  // Apply specs
+  applyCmd *kingpin.CmdClause
+  specDirArg string
+  applyClusterArg string
+  pruneArg bool
+  yesArg bool
+  forceArg bool
+
+  // Alert daemon
+  watchCmd *kingpin.CmdClause
+  alertConfigArg string
+
+  // Scripts
+  runCmd *kingpin.CmdClause
+  scriptFileArg string
+  scriptVarsArg = make(map[string]string)
+  dryRunArg bool
 )
 
 func init() {
@@ -74,6 +93,22 @@ func init() {
   emptyTaskDefinition = taskDefinition.Command("empty", "Print out an full but empty task defintion in JSON format.")
   defaultTaskDefinition = taskDefinition.Command("default", "Print a default task definition in JSON format.")
 
+  applyCmd = app.Command("apply", "Reconcile a directory of service and task definition specs against a cluster.")
+  applyCmd.Flag("file", "Directory of *.service.json and *.task.json specs.").Short('f').Required().StringVar(&specDirArg)
+  applyCmd.Flag("cluster", "Cluster to apply the specs to.").Short('c').Default("minecraft").StringVar(&applyClusterArg)
+  applyCmd.Flag("prune", "Delete services on the cluster that aren't in the specs.").BoolVar(&pruneArg)
+  applyCmd.Flag("yes", "Apply the plan without asking for confirmation.").Short('y').BoolVar(&yesArg)
+  applyCmd.Flag("force", "Needed to prune services on a protected cluster.").BoolVar(&forceArg)
+
+  watchCmd = app.Command("watch", "Run as a daemon, checking clusters against alert rules and sending notifications.")
+  watchCmd.Flag("config", "Alert rules and notifiers, defaults to ~/.ecs-pilot/alerts.yaml.").Short('c').StringVar(&alertConfigArg)
+
+  runCmd = app.Command("run", "Run a script of interactive commands, eg. a runbook.")
+  runCmd.Flag("dry-run", "Print the first AWS call that would change something instead of making it, the command stops there.").BoolVar(&dryRunArg)
+  runCmd.Flag("yes", "Don't ask before destructive commands, protected clusters still need --force.").Short('y').BoolVar(&yesArg)
+  runCmd.Arg("script", "File of commands, see the interactive source command.").Required().StringVar(&scriptFileArg)
+  runCmd.Arg("vars", "Script variables NAME=VALUE.").StringMapVar(&scriptVarsArg)
+
   kingpin.CommandLine.Help = `A command-line AWS ECS tool.`
 
 }
@@ -106,11 +141,14 @@ func main() {
     // describeTaskDefinition.FullCommand(): doDescribeTaskDefinition,
     emptyTaskDefinition.FullCommand(): doEmptyTaskDefinition,
     defaultTaskDefinition.FullCommand(): doDefaultTaskDefinition,
+    applyCmd.FullCommand(): doApply,
+    watchCmd.FullCommand(): doWatch,
+    runCmd.FullCommand(): doRunScript,
   }
 
   // Execute the command.
   if interactiveCmd.FullCommand() == command {
-    interactive.DoInteractive(sess, awsConfig)
+    interactive.DoInteractive(profileArg, sess, awsConfig)
   } else {
     commandMap[command](sess)
   }
@@ -170,6 +208,43 @@ func doDefaultTaskDefinition(*session.Session) {
   printAsJsonObject(tdi)
 }
 
+func doApply(sess *session.Session) {
+  err := interactive.DoApply(specDirArg, applyClusterArg, pruneArg, yesArg, forceArg, sess)
+  interactive.RecordAudit("apply", os.Args[1:], applyClusterArg, err, sess)
+  if err != nil {
+    fmt.Printf("Apply failed: %s\n", err)
+    os.Exit(-1)
+  }
+}
+
+func doWatch(sess *session.Session) {
+  configFile := alertConfigArg
+  if configFile == "" {
+    fn, err := alert.DefaultConfigFile()
+    if err != nil {
+      fmt.Printf("%s\n", err)
+      os.Exit(-1)
+    }
+    configFile = fn
+  }
+  config, err := alert.LoadConfig(configFile)
+  if err != nil {
+    fmt.Printf("Can't load alert config: %s\n", err)
+    os.Exit(-1)
+  }
+  if err = alert.Run(config, sess); err != nil {
+    log.Error(nil, "Alert watch failed.", err)
+    os.Exit(-1)
+  }
+}
+
+func doRunScript(sess *session.Session) {
+  if err := interactive.RunScript(profileArg, scriptFileArg, scriptVarsArg, yesArg, dryRunArg, sess, sess.Config); err != nil {
+    fmt.Printf("%s\n", err)
+    os.Exit(-1)
+  }
+}
+
 func doPrintVersion(*session.Session) {
   fmt.Println(version.Version)
 }
@@ -192,11 +267,13 @@ func configureLogs() {
   switch logsFormatArg {
   case jsonLog:
     log.SetFormatter(new(logrus.JSONFormatter))
+    alert.SetLogFormatter(new(logrus.JSONFormatter))
   case textLog:
     f := new(sl.TextFormatter)
     f.FullTimestamp = true
     log.SetFormatter(f)
     awslib.SetLogFormatter(f)
+    alert.SetLogFormatter(f)
   }
 
   l := logrus.InfoLevel
@@ -205,6 +282,7 @@ func configureLogs() {
   }
   log.SetLevel(l)
   awslib.SetLogLevel(l)
+  alert.SetLogLevel(l)
 }
 
 
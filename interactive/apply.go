@@ -0,0 +1,344 @@
+package interactive
+
+import (
+  "encoding/json"
+  "fmt"
+  "os"
+  "path/filepath"
+  "reflect"
+  "sort"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/awserr"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+// Plan actions, in the order they get applied.
+const (
+  registerAction = iota
+  createAction
+  updateAction
+  deleteAction
+)
+
+type planAction struct {
+  Kind int
+  Name string
+  Details []string
+  apply func() (error)
+}
+
+type applyPlan []*planAction
+
+func (p applyPlan) count(kind int) (n int) {
+  for _, a := range p {
+    if a.Kind == kind { n++ }
+  }
+  return n
+}
+
+// DoApply reconciles the service and task definition specs found in specDir
+// (as written by service export) against the services running on clusterName.
+// It prints the plan, then applies it if confirmed or if autoApprove is set.
+// A plan that deletes services needs force on a protected cluster, even with autoApprove.
+func DoApply(specDir, clusterName string, prune, autoApprove, force bool, sess *session.Session) (error) {
+  serviceSpecs, taskSpecs, err := readSpecs(specDir)
+  if err != nil { return err }
+  if len(serviceSpecs) == 0 && len(taskSpecs) == 0 {
+    return fmt.Errorf("No service (*%s) or task definition (*%s) specs found in %s",
+      serviceSpecSuffix, taskSpecSuffix, specDir)
+  }
+
+  plan, err := makePlan(clusterName, serviceSpecs, taskSpecs, prune, sess)
+  if err != nil { return err }
+
+  printPlan(clusterName, plan)
+  if len(plan) == 0 { return nil }
+
+  if plan.count(deleteAction) > 0 {
+    if err := checkProtected("Delete services", clusterName, force); err != nil { return err }
+    if !autoApprove {
+      ok, err := confirmDestructive(fmt.Sprintf("Apply these changes, deleting %d services, to cluster %s",
+        plan.count(deleteAction), clusterName), clusterName, "", force)
+      if err != nil || !ok { return err }
+    }
+  } else if !autoApprove && !confirm(fmt.Sprintf("Apply these changes to cluster %s?", clusterName)) {
+    fmt.Printf("%sNothing applied.%s\n", warnColor, resetColor)
+    declined = true
+    return nil
+  }
+
+  for _, a := range plan {
+    if err := a.apply(); err != nil {
+      return fmt.Errorf("Failed to %s: %s", a.Name, err)
+    }
+    fmt.Printf("%s%s%s\n", successColor, a.Name, resetColor)
+  }
+  fmt.Printf("%sApply complete.%s\n", successColor, resetColor)
+  return nil
+}
+
+func doApply(specDir, clusterName string, prune, autoApprove, force bool, sess *session.Session) (error) {
+  return DoApply(specDir, clusterName, prune, autoApprove, force, sess)
+}
+
+// Service specs keyed by service name, task definition specs by family.
+func readSpecs(specDir string) (map[string]*ecs.CreateServiceInput, map[string]*ecs.RegisterTaskDefinitionInput, error) {
+  serviceSpecs := make(map[string]*ecs.CreateServiceInput)
+  taskSpecs := make(map[string]*ecs.RegisterTaskDefinitionInput)
+
+  files, err := filepath.Glob(filepath.Join(specDir, "*" + serviceSpecSuffix))
+  if err != nil { return nil, nil, err }
+  for _, fn := range files {
+    spec := new(ecs.CreateServiceInput)
+    if err := readSpec(fn, spec); err != nil { return nil, nil, err }
+    if spec.ServiceName == nil { return nil, nil, fmt.Errorf("No serviceName in %s", fn) }
+    serviceSpecs[*spec.ServiceName] = spec
+  }
+
+  files, err = filepath.Glob(filepath.Join(specDir, "*" + taskSpecSuffix))
+  if err != nil { return nil, nil, err }
+  for _, fn := range files {
+    spec := new(ecs.RegisterTaskDefinitionInput)
+    if err := readSpec(fn, spec); err != nil { return nil, nil, err }
+    if spec.Family == nil { return nil, nil, fmt.Errorf("No family in %s", fn) }
+    taskSpecs[*spec.Family] = spec
+  }
+  return serviceSpecs, taskSpecs, nil
+}
+
+func readSpec(fileName string, spec interface{}) (error) {
+  file, err := os.Open(fileName)
+  if err != nil { return err }
+  defer file.Close()
+  if err = jsonutil.UnmarshalJSON(spec, file); err != nil {
+    return fmt.Errorf("Couldn't read spec %s: %s", fileName, err)
+  }
+  return nil
+}
+
+func makePlan(clusterName string, serviceSpecs map[string]*ecs.CreateServiceInput,
+  taskSpecs map[string]*ecs.RegisterTaskDefinitionInput, prune bool, sess *session.Session) (plan applyPlan, err error) {
+
+  ecsSvc := ecs.New(sess)
+
+  // Task definitions first, services pick up new revisions by family.
+  registering := make(map[string]bool)
+  for _, family := range sortedSpecKeys(taskSpecs) {
+    spec := taskSpecs[family]
+    current, err := awslib.GetTaskDefinition(family, sess)
+    if err != nil && !isClientException(err) {
+      return nil, fmt.Errorf("Can't get task definition %s: %s", family, err)
+    }
+    if err == nil && specsEqual(taskDefinitionSpec(current), spec) { continue }
+
+    a := &planAction{Kind: registerAction, Name: fmt.Sprintf("register task definition %s", family)}
+    if err == nil {
+      a.Details = append(a.Details, fmt.Sprintf("changed from %s", awslib.ShortArnString(current.TaskDefinitionArn)))
+    } else {
+      a.Details = append(a.Details, "new family")
+    }
+    // Register what was read and shown in the plan, the file name needn't match the family.
+    input := spec
+    a.apply = func() (error) {
+      _, err := ecsSvc.RegisterTaskDefinition(input)
+      return err
+    }
+    registering[family] = true
+    plan = append(plan, a)
+  }
+
+  services, failures, err := awslib.DescribeServices(clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return nil, err }
+  current := make(map[string]*ecs.Service)
+  for _, s := range services {
+    if *s.Status == "ACTIVE" { current[*s.ServiceName] = s }
+  }
+
+  for _, name := range sortedSpecKeys(serviceSpecs) {
+    spec := serviceSpecs[name]
+    family := strings.Split(aws.StringValue(spec.TaskDefinition), ":")[0]
+    s, ok := current[name]
+    if !ok {
+      input := *spec
+      input.Cluster = aws.String(clusterName)
+      plan = append(plan, &planAction{
+        Kind: createAction,
+        Name: fmt.Sprintf("create service %s", name),
+        Details: []string{
+          fmt.Sprintf("taskDefinition: %s", aws.StringValue(spec.TaskDefinition)),
+          fmt.Sprintf("desiredCount: %d", aws.Int64Value(spec.DesiredCount)),
+        },
+        apply: func() (error) {
+          _, err := ecsSvc.CreateService(&input)
+          return err
+        },
+      })
+      continue
+    }
+
+    a, err := serviceUpdateAction(name, clusterName, family, spec, s, registering[family], sess)
+    if err != nil { return nil, err }
+    if a != nil { plan = append(plan, a) }
+  }
+
+  if prune {
+    names := make([]string, 0)
+    for name := range current {
+      if _, ok := serviceSpecs[name]; !ok { names = append(names, name) }
+    }
+    sort.Strings(names)
+    for _, name := range names {
+      serviceName := name
+      plan = append(plan, &planAction{
+        Kind: deleteAction,
+        Name: fmt.Sprintf("delete service %s", serviceName),
+        Details: []string{"not in specs"},
+        apply: func() (error) {
+          if _, err := awslib.UpdateServiceDesiredCount(serviceName, clusterName, 0, sess); err != nil { return err }
+          _, err := awslib.DeleteService(serviceName, clusterName, sess)
+          return err
+        },
+      })
+    }
+  }
+
+  sort.SliceStable(plan, func(i, j int) bool { return plan[i].Kind < plan[j].Kind })
+  return plan, nil
+}
+
+// Returns nil if the running service already matches the spec.
+func serviceUpdateAction(name, clusterName, family string, spec *ecs.CreateServiceInput, s *ecs.Service,
+  newRevision bool, sess *session.Session) (*planAction, error) {
+
+  a := &planAction{Kind: updateAction, Name: fmt.Sprintf("update service %s", name)}
+  input := &ecs.UpdateServiceInput{Cluster: aws.String(clusterName), Service: aws.String(name)}
+
+  currentTd := awslib.ShortArnString(s.TaskDefinition)
+  wantTd := aws.StringValue(spec.TaskDefinition)
+  if newRevision {
+    wantTd = family + ":(new revision)"
+  } else if !strings.Contains(wantTd, ":") {
+    latest, err := awslib.GetTaskDefinition(family, sess)
+    if err != nil { return nil, fmt.Errorf("Can't find task definition %s for service %s: %s", family, name, err) }
+    wantTd = awslib.ShortArnString(latest.TaskDefinitionArn)
+  }
+  if wantTd != currentTd {
+    a.Details = append(a.Details, fmt.Sprintf("taskDefinition: %s => %s", currentTd, wantTd))
+    input.TaskDefinition = aws.String(strings.Replace(wantTd, ":(new revision)", "", 1))
+  }
+
+  if spec.DesiredCount != nil && *spec.DesiredCount != *s.DesiredCount {
+    a.Details = append(a.Details, fmt.Sprintf("desiredCount: %d => %d", *s.DesiredCount, *spec.DesiredCount))
+    input.DesiredCount = spec.DesiredCount
+  }
+
+  if spec.DeploymentConfiguration != nil && !specsEqual(spec.DeploymentConfiguration, s.DeploymentConfiguration) {
+    a.Details = append(a.Details, "deploymentConfiguration changed")
+    input.DeploymentConfiguration = spec.DeploymentConfiguration
+  }
+
+  // These can't be changed with UpdateService, say so rather than pretend.
+  if !specsEqual(spec.LoadBalancers, s.LoadBalancers) {
+    a.Details = append(a.Details, fmt.Sprintf("%sloadBalancers differ, recreate the service to change them%s", warnColor, resetColor))
+  }
+
+  if input.TaskDefinition == nil && input.DesiredCount == nil && input.DeploymentConfiguration == nil {
+    if len(a.Details) > 0 {
+      fmt.Printf("%sService %s: %s%s\n", warnColor, name, strings.Join(a.Details, ", "), resetColor)
+    }
+    return nil, nil
+  }
+
+  a.apply = func() (error) {
+    if input.TaskDefinition == nil && input.DeploymentConfiguration == nil {
+      _, err := awslib.UpdateServiceDesiredCount(name, clusterName, *input.DesiredCount, sess)
+      return err
+    }
+    _, err := ecs.New(sess).UpdateService(input)
+    return err
+  }
+  return a, nil
+}
+
+func printPlan(clusterName string, plan applyPlan) {
+  fmt.Printf("%sPlan for cluster %s:%s\n", titleColor, clusterName, resetColor)
+  if len(plan) == 0 {
+    fmt.Printf("%sNo changes. The cluster matches the specs.%s\n", successColor, resetColor)
+    return
+  }
+  for _, a := range plan {
+    mark, color := "+", successColor
+    switch a.Kind {
+    case updateAction: mark, color = "~", warnColor
+    case deleteAction: mark, color = "-", failColor
+    }
+    fmt.Printf("%s%s %s%s\n", color, mark, a.Name, resetColor)
+    for _, d := range a.Details {
+      fmt.Printf("      %s\n", d)
+    }
+  }
+  fmt.Printf("\n%sPlan: %d to register, %d to create, %d to update, %d to delete.%s\n", titleColor,
+    plan.count(registerAction), plan.count(createAction), plan.count(updateAction), plan.count(deleteAction), resetColor)
+}
+
+// Compares two AWS structures through their JSON, ignoring empty values
+// AWS leaves out of one side but not the other.
+func specsEqual(a, b interface{}) (bool) {
+  na, err := normalizedSpec(a)
+  if err != nil { return false }
+  nb, err := normalizedSpec(b)
+  if err != nil { return false }
+  return reflect.DeepEqual(na, nb)
+}
+
+func normalizedSpec(spec interface{}) (interface{}, error) {
+  b, err := jsonutil.BuildJSON(spec)
+  if err != nil { return nil, err }
+  var v interface{}
+  if err = json.Unmarshal(b, &v); err != nil { return nil, err }
+  return pruneEmpty(v), nil
+}
+
+func pruneEmpty(v interface{}) (interface{}) {
+  switch t := v.(type) {
+  case map[string]interface{}:
+    for k, e := range t {
+      p := pruneEmpty(e)
+      if p == nil {
+        delete(t, k)
+      } else {
+        t[k] = p
+      }
+    }
+    if len(t) == 0 { return nil }
+  case []interface{}:
+    l := make([]interface{}, 0, len(t))
+    for _, e := range t {
+      if p := pruneEmpty(e); p != nil { l = append(l, p) }
+    }
+    if len(l) == 0 { return nil }
+    return l
+  }
+  return v
+}
+
+// ECS answers a ClientException for a task definition family it doesn't have.
+func isClientException(err error) (bool) {
+  if aerr, ok := err.(awserr.Error); ok { return aerr.Code() == ecs.ErrCodeClientException }
+  return false
+}
+
+func sortedSpecKeys(m interface{}) (keys []string) {
+  for _, k := range reflect.ValueOf(m).MapKeys() {
+    keys = append(keys, k.String())
+  }
+  sort.Strings(keys)
+  return keys
+}
@@ -0,0 +1,74 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/awserr"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestSpecsEqual(t *testing.T) {
+  spec := &ecs.RegisterTaskDefinitionInput{
+    Family: aws.String("web"),
+    ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("web"), Memory: aws.Int64(512)}},
+  }
+  same := &ecs.RegisterTaskDefinitionInput{
+    Family: aws.String("web"),
+    ContainerDefinitions: []*ecs.ContainerDefinition{
+      {Name: aws.String("web"), Memory: aws.Int64(512), Environment: []*ecs.KeyValuePair{}, Links: []*string{}},
+    },
+    Volumes: []*ecs.Volume{},
+  }
+  assert.True(t, specsEqual(spec, same), "Empty lists on one side only shouldn't count as a change.")
+
+  changed := &ecs.RegisterTaskDefinitionInput{
+    Family: aws.String("web"),
+    ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("web"), Memory: aws.Int64(1024)}},
+  }
+  assert.False(t, specsEqual(spec, changed))
+}
+
+func TestPruneEmpty(t *testing.T) {
+  v := map[string]interface{}{
+    "name": "web",
+    "empty": map[string]interface{}{},
+    "list": []interface{}{map[string]interface{}{}, "a"},
+    "none": []interface{}{},
+    "nested": map[string]interface{}{"inner": []interface{}{}},
+  }
+  assert.Equal(t, map[string]interface{}{"name": "web", "list": []interface{}{"a"}}, pruneEmpty(v))
+  assert.Nil(t, pruneEmpty(map[string]interface{}{"a": []interface{}{}}))
+  assert.Equal(t, "x", pruneEmpty("x"))
+}
+
+func TestServiceUpdateAction(t *testing.T) {
+  s := &ecs.Service{
+    ServiceName: aws.String("web"),
+    TaskDefinition: aws.String("arn:aws:ecs:us-east-1:123456789012:task-definition/web:3"),
+    DesiredCount: aws.Int64(2),
+  }
+
+  spec := &ecs.CreateServiceInput{ServiceName: aws.String("web"), TaskDefinition: aws.String("web:3"), DesiredCount: aws.Int64(2)}
+  a, err := serviceUpdateAction("web", "test", "web", spec, s, false, nil)
+  if assert.NoError(t, err) { assert.Nil(t, a, "Nothing to update.") }
+
+  spec.DesiredCount = aws.Int64(4)
+  a, err = serviceUpdateAction("web", "test", "web", spec, s, false, nil)
+  if assert.NoError(t, err) && assert.NotNil(t, a) {
+    assert.Equal(t, updateAction, a.Kind)
+    assert.Equal(t, []string{"desiredCount: 2 => 4"}, a.Details)
+  }
+
+  spec.DesiredCount = aws.Int64(2)
+  a, err = serviceUpdateAction("web", "test", "web", spec, s, true, nil)
+  if assert.NoError(t, err) && assert.NotNil(t, a) {
+    assert.Equal(t, []string{"taskDefinition: web:3 => web:(new revision)"}, a.Details)
+  }
+}
+
+func TestIsClientException(t *testing.T) {
+  assert.True(t, isClientException(awserr.New(ecs.ErrCodeClientException, "Unable to describe task definition.", nil)))
+  assert.False(t, isClientException(awserr.New("AccessDeniedException", "not allowed", nil)))
+  assert.False(t, isClientException(nil))
+}
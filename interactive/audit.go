@@ -0,0 +1,140 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "regexp"
+  "strings"
+  "text/tabwriter"
+  "time"
+  "ecs-pilot/audit"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/jdrivas/awslib"
+  "github.com/alecthomas/kingpin"
+)
+
+const auditFileName = "audit.log"
+
+var (
+  auditLog *audit.Log
+  auditIdentity string // The AWS caller, looked up once.
+  auditedCommands = make(map[string]bool)
+  declined bool // Set when a confirmation is refused, so nothing was done.
+)
+
+// Commands that change something get an audit entry.
+func audited(cmds ...*kingpin.CmdClause) {
+  for _, c := range cmds { auditedCommands[c.FullCommand()] = true }
+}
+
+func getAuditLog() (*audit.Log, error) {
+  if auditLog != nil { return auditLog, nil }
+  fn, err := configFilePath(auditFileName)
+  if err != nil { return nil, err }
+  config, err := getConfig()
+  if err != nil { return nil, err }
+  auditLog, err = audit.New(config.Audit, fn)
+  return auditLog, err
+}
+
+func callerIdentity(sess *session.Session) (string) {
+  if auditIdentity == "" && sess != nil {
+    if id, err := awslib.GetCurrentAccountIdentity(sess); err == nil && id.Arn != nil {
+      auditIdentity = *id.Arn
+    }
+  }
+  return auditIdentity
+}
+
+// Records command, as run from line on clusterName, if it's one that changes things.
+func recordAudit(command, line, clusterName string, err error) {
+  if !auditedCommands[command] || dryRun() { return }
+  RecordAudit(command, auditArguments(command, line), clusterName, err, currentSession)
+}
+
+// Records a change made from the ecs-pilot command line, rather than the prompt.
+func RecordAudit(command string, args []string, clusterName string, err error, sess *session.Session) {
+  l, lerr := getAuditLog()
+  if lerr != nil {
+    fmt.Printf("%sNo audit log: %s%s\n", warnColor, lerr, resetColor)
+    return
+  }
+
+  e := audit.Entry{Time: time.Now(), User: audit.LocalUser(), Identity: callerIdentity(sess),
+    Cluster: clusterName, Command: command, Arguments: args, Result: audit.ResultOk}
+  switch {
+  case err != nil && err != errBadCommand:
+    e.Result = audit.ResultFailed
+    e.Error = err.Error()
+  case declined:
+    e.Result = audit.ResultDeclined
+  }
+  if rerr := l.Record(e); rerr != nil { fmt.Printf("%s%s%s\n", warnColor, rerr, resetColor) }
+}
+
+// The words of line less those naming the command, with task environment values masked.
+func auditArguments(command, line string) ([]string) {
+  masked, wasMasked := maskSecrets(line)
+  words, err := tokenize(masked)
+  if err != nil { return nil }
+  if !wasMasked {
+    if expanded, err := expandAlias(words, getAliases()); err == nil { words = expanded }
+  }
+
+  args := make([]string, 0, len(words))
+  commandWords := strings.Fields(command)
+  for _, w := range words {
+    if len(commandWords) > 0 && w == commandWords[0] {
+      commandWords = commandWords[1:]
+      continue
+    }
+    args = append(args, w)
+  }
+  return args
+}
+
+// The last count entries from the local audit file, filtered by cluster, user and grep.
+func doAuditShow(clusterName, userName, grep string, count int) (error) {
+  var re *regexp.Regexp
+  if grep != "" {
+    var err error
+    if re, err = regexp.Compile("(?i)" + grep); err != nil { return fmt.Errorf("Bad --grep pattern: %s", err) }
+  }
+  l, err := getAuditLog()
+  if err != nil { return err }
+  entries, err := audit.ReadFile(l.FilePath)
+  if err != nil { return err }
+
+  shown := make([]audit.Entry, 0)
+  for _, e := range entries {
+    if clusterName != "" && e.Cluster != clusterName { continue }
+    if userName != "" && e.User != userName { continue }
+    if re != nil && !re.MatchString(e.Command + " " + strings.Join(e.Arguments, " ")) { continue }
+    shown = append(shown, e)
+  }
+  if len(shown) == 0 {
+    fmt.Printf("No matching audit entries in %s.\n", l.FilePath)
+    return nil
+  }
+  if count > 0 && len(shown) > count { shown = shown[len(shown)-count:] }
+
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sTime\tUser\tIdentity\tCluster\tCommand\tResult%s\n", titleColor, resetColor)
+  for _, e := range shown {
+    color := nullColor
+    result := e.Result
+    switch e.Result {
+    case audit.ResultFailed:
+      color = failColor
+      result += ": " + e.Error
+    case audit.ResultDeclined:
+      color = warnColor
+    }
+    identity := ""
+    if e.Identity != "" { identity = awslib.ShortArnString(&e.Identity) }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s%s\n", color, e.Time.Local().Format(humanTimeFormat), e.User, identity,
+      e.Cluster, strings.TrimSpace(e.Command + " " + strings.Join(e.Arguments, " ")), result, resetColor)
+  }
+  w.Flush()
+  return nil
+}
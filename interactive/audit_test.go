@@ -0,0 +1,24 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestAuditArguments(t *testing.T) {
+  aliases = map[string]string{"tr": "task run", "sd": "service delete"}
+  tests := []struct {
+    command string
+    line string
+    args []string
+  }{
+    {"service delete", "service delete web prod --force", []string{"web", "prod", "--force"}},
+    {"service delete", "sd web", []string{"web"}},
+    {"task run", "task run web prod DB_PASSWORD=hunter2", []string{"web", "prod", "DB_PASSWORD=****"}},
+    {"task run", "tr web KEY=v", []string{"web", "KEY=****"}},
+    {"task stop", "task --yes stop 1234", []string{"--yes", "1234"}},
+  }
+  for _, test := range tests {
+    assert.Equal(t, test.args, auditArguments(test.command, test.line), test.line)
+  }
+}
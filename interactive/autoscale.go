@@ -0,0 +1,173 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/applicationautoscaling"
+)
+
+// Application Auto Scaling names for an ECS service's desired count.
+const (
+  ecsScalingNamespace = "ecs"
+  ecsScalingDimension = "ecs:service:DesiredCount"
+  cpuMetricType = "ECSServiceAverageCPUUtilization"
+  memoryMetricType = "ECSServiceAverageMemoryUtilization"
+)
+
+func scalingResourceId(serviceName, clusterName string) (string) {
+  return fmt.Sprintf("service/%s/%s", clusterName, serviceName)
+}
+
+func scalingPolicyName(serviceName, metric string) (string) {
+  return fmt.Sprintf("%s-%s-target-tracking", serviceName, metric)
+}
+
+func getServiceScaling(serviceName, clusterName string, sess *session.Session) (*applicationautoscaling.ScalableTarget, []*applicationautoscaling.ScalingPolicy, error) {
+  svc := applicationautoscaling.New(sess)
+  resourceId := scalingResourceId(serviceName, clusterName)
+
+  targets, err := svc.DescribeScalableTargets(&applicationautoscaling.DescribeScalableTargetsInput{
+    ServiceNamespace: aws.String(ecsScalingNamespace),
+    ScalableDimension: aws.String(ecsScalingDimension),
+    ResourceIds: []*string{aws.String(resourceId)},
+  })
+  if err != nil { return nil, nil, err }
+  if len(targets.ScalableTargets) == 0 { return nil, nil, nil }
+
+  policies, err := svc.DescribeScalingPolicies(&applicationautoscaling.DescribeScalingPoliciesInput{
+    ServiceNamespace: aws.String(ecsScalingNamespace),
+    ScalableDimension: aws.String(ecsScalingDimension),
+    ResourceId: aws.String(resourceId),
+  })
+  if err != nil { return targets.ScalableTargets[0], nil, err }
+  return targets.ScalableTargets[0], policies.ScalingPolicies, nil
+}
+
+func doShowServiceAutoScaling(serviceName, clusterName string, sess *session.Session) (error) {
+  target, policies, err := getServiceScaling(serviceName, clusterName, sess)
+  if err != nil { return err }
+  printServiceAutoScaling(target, policies)
+  return nil
+}
+
+// min and max of -1, or a target of 0, leave the current setting alone.
+func doSetServiceAutoScaling(serviceName, clusterName string, min, max int64, cpuTarget, memTarget float64, sess *session.Session) (error) {
+  svc := applicationautoscaling.New(sess)
+  resourceId := scalingResourceId(serviceName, clusterName)
+
+  target, _, err := getServiceScaling(serviceName, clusterName, sess)
+  if err != nil { return err }
+  if target == nil && (min < 0 || max < 0) {
+    return fmt.Errorf("Service %s has no scalable target yet, both --min and --max are required", serviceName)
+  }
+
+  if min >= 0 || max >= 0 {
+    input := &applicationautoscaling.RegisterScalableTargetInput{
+      ServiceNamespace: aws.String(ecsScalingNamespace),
+      ScalableDimension: aws.String(ecsScalingDimension),
+      ResourceId: aws.String(resourceId),
+    }
+    if min >= 0 { input.MinCapacity = aws.Int64(min) }
+    if max >= 0 { input.MaxCapacity = aws.Int64(max) }
+    if _, err = svc.RegisterScalableTarget(input); err != nil {
+      return fmt.Errorf("Failed to register scalable target for %s: %s", serviceName, err)
+    }
+  }
+
+  targets := []struct {
+    metric string
+    metricType string
+    value float64
+  }{
+    {"cpu", cpuMetricType, cpuTarget},
+    {"memory", memoryMetricType, memTarget},
+  }
+  for _, t := range targets {
+    if t.value <= 0 { continue }
+    _, err = svc.PutScalingPolicy(&applicationautoscaling.PutScalingPolicyInput{
+      PolicyName: aws.String(scalingPolicyName(serviceName, t.metric)),
+      PolicyType: aws.String(applicationautoscaling.PolicyTypeTargetTrackingScaling),
+      ServiceNamespace: aws.String(ecsScalingNamespace),
+      ScalableDimension: aws.String(ecsScalingDimension),
+      ResourceId: aws.String(resourceId),
+      TargetTrackingScalingPolicyConfiguration: &applicationautoscaling.TargetTrackingScalingPolicyConfiguration{
+        TargetValue: aws.Float64(t.value),
+        PredefinedMetricSpecification: &applicationautoscaling.PredefinedMetricSpecification{
+          PredefinedMetricType: aws.String(t.metricType),
+        },
+      },
+    })
+    if err != nil { return fmt.Errorf("Failed to set %s target tracking policy for %s: %s", t.metric, serviceName, err) }
+  }
+
+  fmt.Printf("%sAuto scaling updated for %s on cluster %s.%s\n", successColor, serviceName, clusterName, resetColor)
+  return doShowServiceAutoScaling(serviceName, clusterName, sess)
+}
+
+func doRemoveServiceAutoScaling(serviceName, clusterName string, sess *session.Session) (error) {
+  svc := applicationautoscaling.New(sess)
+  target, policies, err := getServiceScaling(serviceName, clusterName, sess)
+  if err != nil { return err }
+  if target == nil {
+    fmt.Printf("%sService %s has no auto scaling configured.%s\n", warnColor, serviceName, resetColor)
+    return nil
+  }
+
+  for _, p := range policies {
+    _, err = svc.DeleteScalingPolicy(&applicationautoscaling.DeleteScalingPolicyInput{
+      PolicyName: p.PolicyName,
+      ServiceNamespace: p.ServiceNamespace,
+      ScalableDimension: p.ScalableDimension,
+      ResourceId: p.ResourceId,
+    })
+    if err != nil { return fmt.Errorf("Failed to delete scaling policy %s: %s", *p.PolicyName, err) }
+  }
+
+  _, err = svc.DeregisterScalableTarget(&applicationautoscaling.DeregisterScalableTargetInput{
+    ServiceNamespace: target.ServiceNamespace,
+    ScalableDimension: target.ScalableDimension,
+    ResourceId: target.ResourceId,
+  })
+  if err == nil {
+    fmt.Printf("%sRemoved auto scaling (%d policies) from %s on cluster %s.%s\n",
+      successColor, len(policies), serviceName, clusterName, resetColor)
+  }
+  return err
+}
+
+func printServiceAutoScaling(target *applicationautoscaling.ScalableTarget, policies []*applicationautoscaling.ScalingPolicy) {
+  fmt.Printf("\n%sAuto Scaling%s\n", titleColor, resetColor)
+  if target == nil {
+    fmt.Printf("There is no auto scaling for this service.\n")
+    return
+  }
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sMin\tMax\tCreated%s\n", titleColor, resetColor)
+  fmt.Fprintf(w, "%s%d\t%d\t%s%s\n", nullColor, *target.MinCapacity, *target.MaxCapacity,
+    target.CreationTime.Local().Format(humanTimeFormat), resetColor)
+  w.Flush()
+
+  if len(policies) == 0 {
+    fmt.Printf("There are no scaling policies for this service.\n")
+    return
+  }
+  w = tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sPolicy\tType\tMetric\tTarget\tAlarms%s\n", titleColor, resetColor)
+  for _, p := range policies {
+    metric, targetValue := "<none>", "<none>"
+    if tt := p.TargetTrackingScalingPolicyConfiguration; tt != nil {
+      targetValue = fmt.Sprintf("%.1f", *tt.TargetValue)
+      if tt.PredefinedMetricSpecification != nil {
+        metric = *tt.PredefinedMetricSpecification.PredefinedMetricType
+      } else if tt.CustomizedMetricSpecification != nil {
+        metric = *tt.CustomizedMetricSpecification.MetricName
+      }
+    }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%d%s\n", nullColor,
+      *p.PolicyName, *p.PolicyType, metric, targetValue, len(p.Alarms), resetColor)
+  }
+  w.Flush()
+}
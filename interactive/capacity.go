@@ -0,0 +1,237 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/autoscaling"
+  "github.com/aws/aws-sdk-go/service/ecs"
+)
+
+const (
+  protectionEnabled = "enabled"
+  protectionDisabled = "disabled"
+)
+
+// Capacity provider changes. Negative counts, a zero target and an
+// empty protection leave the current setting alone.
+type capacitySettings struct {
+  Min int64
+  Max int64
+  Desired int64
+  TargetCapacity int64
+  Protection string
+}
+
+func describeCluster(clusterName string, sess *session.Session) (*ecs.Cluster, error) {
+  resp, err := ecs.New(sess).DescribeClusters(&ecs.DescribeClustersInput{Clusters: []*string{aws.String(clusterName)}})
+  if err != nil { return nil, err }
+  if len(resp.Clusters) == 0 {
+    if len(resp.Failures) > 0 { printFailures(resp.Failures) }
+    return nil, fmt.Errorf("Cluster %s not found", clusterName)
+  }
+  return resp.Clusters[0], nil
+}
+
+func describeCapacityProviders(names []*string, sess *session.Session) ([]*ecs.CapacityProvider, error) {
+  if len(names) == 0 { return []*ecs.CapacityProvider{}, nil }
+  resp, err := ecs.New(sess).DescribeCapacityProviders(&ecs.DescribeCapacityProvidersInput{CapacityProviders: names})
+  if err != nil { return nil, err }
+  if len(resp.Failures) > 0 { printFailures(resp.Failures) }
+  return resp.CapacityProviders, nil
+}
+
+// Capacity providers only keep the ARN of their group, the name is at the end of it.
+func asgNameFromArn(arn string) (string) {
+  parts := strings.SplitN(arn, "autoScalingGroupName/", 2)
+  if len(parts) == 2 { return parts[1] }
+  return arn
+}
+
+// Auto Scaling groups for the providers keyed by group name.
+// Fargate providers don't have one.
+func providerGroups(providers []*ecs.CapacityProvider, sess *session.Session) (map[string]*autoscaling.Group, error) {
+  groups := make(map[string]*autoscaling.Group)
+  names := make([]*string, 0)
+  for _, cp := range providers {
+    if cp.AutoScalingGroupProvider != nil {
+      names = append(names, aws.String(asgNameFromArn(*cp.AutoScalingGroupProvider.AutoScalingGroupArn)))
+    }
+  }
+  if len(names) == 0 { return groups, nil }
+  resp, err := autoscaling.New(sess).DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+    AutoScalingGroupNames: names,
+  })
+  if err != nil { return groups, err }
+  for _, g := range resp.AutoScalingGroups {
+    groups[*g.AutoScalingGroupName] = g
+  }
+  return groups, nil
+}
+
+func providerGroup(cp *ecs.CapacityProvider, groups map[string]*autoscaling.Group) (*autoscaling.Group) {
+  if cp.AutoScalingGroupProvider == nil { return nil }
+  return groups[asgNameFromArn(*cp.AutoScalingGroupProvider.AutoScalingGroupArn)]
+}
+
+func doListCapacity(clusterName string, sess *session.Session) (error) {
+  c, err := describeCluster(clusterName, sess)
+  if err != nil { return err }
+  providers, err := describeCapacityProviders(c.CapacityProviders, sess)
+  if err != nil { return err }
+  groups, err := providerGroups(providers, sess)
+  if err != nil { return err }
+
+  fmt.Printf("%sCluster %s: %d capacity providers.%s\n", titleColor, clusterName, len(providers), resetColor)
+  if len(providers) > 0 {
+    w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+    fmt.Fprintf(w, "%sProvider\tStatus\tASG\tMin\tMax\tDesired\tInstances\tManaged Scaling\tTarget%%\tTermination Protection%s\n", titleColor, resetColor)
+    for _, cp := range providers {
+      printCapacityProviderLine(w, cp, providerGroup(cp, groups))
+    }
+    w.Flush()
+  }
+  printCapacityProviderStrategy(c.DefaultCapacityProviderStrategy)
+  return nil
+}
+
+func printCapacityProviderLine(w *tabwriter.Writer, cp *ecs.CapacityProvider, g *autoscaling.Group) {
+  asgName, min, max, desired, instances := "<none>", "-", "-", "-", "-"
+  if g != nil {
+    asgName = *g.AutoScalingGroupName
+    min = fmt.Sprintf("%d", *g.MinSize)
+    max = fmt.Sprintf("%d", *g.MaxSize)
+    desired = fmt.Sprintf("%d", *g.DesiredCapacity)
+    instances = fmt.Sprintf("%d", len(g.Instances))
+  }
+  scaling, target, protection := "-", "-", "-"
+  if asgp := cp.AutoScalingGroupProvider; asgp != nil {
+    protection = aws.StringValue(asgp.ManagedTerminationProtection)
+    if ms := asgp.ManagedScaling; ms != nil {
+      scaling = aws.StringValue(ms.Status)
+      target = fmt.Sprintf("%d", aws.Int64Value(ms.TargetCapacity))
+    }
+  }
+  fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor,
+    *cp.Name, aws.StringValue(cp.Status), asgName, min, max, desired, instances, scaling, target, protection, resetColor)
+}
+
+func printCapacityProviderStrategy(strategy []*ecs.CapacityProviderStrategyItem) {
+  fmt.Printf("\n%sDefault Capacity Provider Strategy:%s\n", titleColor, resetColor)
+  if len(strategy) == 0 {
+    fmt.Printf("There is no default capacity provider strategy for this cluster.\n")
+    return
+  }
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sProvider\tBase\tWeight%s\n", titleColor, resetColor)
+  for _, item := range strategy {
+    fmt.Fprintf(w, "%s%s\t%d\t%d%s\n", nullColor,
+      *item.CapacityProvider, aws.Int64Value(item.Base), aws.Int64Value(item.Weight), resetColor)
+  }
+  w.Flush()
+}
+
+func doDescribeCapacity(providerName string, sess *session.Session) (error) {
+  providers, err := describeCapacityProviders([]*string{aws.String(providerName)}, sess)
+  if err != nil { return err }
+  if len(providers) == 0 { return fmt.Errorf("Capacity provider %s not found", providerName) }
+  cp := providers[0]
+  groups, err := providerGroups(providers, sess)
+  if err != nil { return err }
+  g := providerGroup(cp, groups)
+
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sProvider\tStatus\tASG\tMin\tMax\tDesired\tInstances\tManaged Scaling\tTarget%%\tTermination Protection%s\n", titleColor, resetColor)
+  printCapacityProviderLine(w, cp, g)
+  w.Flush()
+
+  if asgp := cp.AutoScalingGroupProvider; asgp != nil && asgp.ManagedScaling != nil {
+    ms := asgp.ManagedScaling
+    fmt.Printf("\n%sManaged Scaling%s\n", titleColor, resetColor)
+    w = tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+    fmt.Fprintf(w, "%sStatus\tTarget%%\tMin Step\tMax Step\tWarmup%s\n", titleColor, resetColor)
+    fmt.Fprintf(w, "%s%s\t%d\t%d\t%d\t%ds%s\n", nullColor, aws.StringValue(ms.Status), aws.Int64Value(ms.TargetCapacity),
+      aws.Int64Value(ms.MinimumScalingStepSize), aws.Int64Value(ms.MaximumScalingStepSize),
+      aws.Int64Value(ms.InstanceWarmupPeriod), resetColor)
+    w.Flush()
+  }
+
+  if g == nil { return nil }
+  fmt.Printf("\n%sAuto Scaling Group %s instances (%d), new instances protected from scale in: %t%s\n", titleColor,
+    *g.AutoScalingGroupName, len(g.Instances), aws.BoolValue(g.NewInstancesProtectedFromScaleIn), resetColor)
+  if len(g.Instances) > 0 {
+    w = tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+    fmt.Fprintf(w, "%sInstance ID\tType\tZone\tLifecycle\tHealth\tScale-In Protected%s\n", titleColor, resetColor)
+    for _, i := range g.Instances {
+      color := nullColor
+      if aws.StringValue(i.HealthStatus) != "Healthy" { color = warnColor }
+      fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%t%s\n", color, *i.InstanceId, aws.StringValue(i.InstanceType),
+        aws.StringValue(i.AvailabilityZone), aws.StringValue(i.LifecycleState), aws.StringValue(i.HealthStatus),
+        aws.BoolValue(i.ProtectedFromScaleIn), resetColor)
+    }
+    w.Flush()
+  }
+  return nil
+}
+
+func doSetCapacity(providerName string, settings capacitySettings, sess *session.Session) (error) {
+  providers, err := describeCapacityProviders([]*string{aws.String(providerName)}, sess)
+  if err != nil { return err }
+  if len(providers) == 0 { return fmt.Errorf("Capacity provider %s not found", providerName) }
+  cp := providers[0]
+  if cp.AutoScalingGroupProvider == nil {
+    return fmt.Errorf("Capacity provider %s has no Auto Scaling group to change", providerName)
+  }
+  asgName := asgNameFromArn(*cp.AutoScalingGroupProvider.AutoScalingGroupArn)
+  asSvc := autoscaling.New(sess)
+
+  update := &autoscaling.UpdateAutoScalingGroupInput{AutoScalingGroupName: aws.String(asgName)}
+  changed := false
+  if settings.Min >= 0 { update.MinSize = aws.Int64(settings.Min); changed = true }
+  if settings.Max >= 0 { update.MaxSize = aws.Int64(settings.Max); changed = true }
+  if settings.Desired >= 0 { update.DesiredCapacity = aws.Int64(settings.Desired); changed = true }
+  if settings.Protection != "" {
+    update.NewInstancesProtectedFromScaleIn = aws.Bool(settings.Protection == protectionEnabled)
+    changed = true
+  }
+  if changed {
+    if _, err = asSvc.UpdateAutoScalingGroup(update); err != nil {
+      return fmt.Errorf("Failed to update Auto Scaling group %s: %s", asgName, err)
+    }
+  }
+
+  // Protection also applies to the instances already in the group.
+  if settings.Protection != "" {
+    groups, err := providerGroups(providers, sess)
+    if err != nil { return err }
+    if g := groups[asgName]; g != nil && len(g.Instances) > 0 {
+      ids := make([]*string, 0, len(g.Instances))
+      for _, i := range g.Instances { ids = append(ids, i.InstanceId) }
+      _, err = asSvc.SetInstanceProtection(&autoscaling.SetInstanceProtectionInput{
+        AutoScalingGroupName: aws.String(asgName),
+        InstanceIds: ids,
+        ProtectedFromScaleIn: aws.Bool(settings.Protection == protectionEnabled),
+      })
+      if err != nil { return fmt.Errorf("Failed to set instance protection on %s: %s", asgName, err) }
+    }
+  }
+
+  if settings.TargetCapacity > 0 {
+    // ECS replaces all of ManagedScaling, so keep the step sizes and warmup there are.
+    ms := ecs.ManagedScaling{}
+    if current := cp.AutoScalingGroupProvider.ManagedScaling; current != nil { ms = *current }
+    ms.Status = aws.String(ecs.ManagedScalingStatusEnabled)
+    ms.TargetCapacity = aws.Int64(settings.TargetCapacity)
+    _, err = ecs.New(sess).UpdateCapacityProvider(&ecs.UpdateCapacityProviderInput{
+      Name: aws.String(providerName),
+      AutoScalingGroupProvider: &ecs.AutoScalingGroupProviderUpdate{ManagedScaling: &ms},
+    })
+    if err != nil { return fmt.Errorf("Failed to update managed scaling for %s: %s", providerName, err) }
+  }
+
+  fmt.Printf("%sUpdated capacity provider %s.%s\n", successColor, providerName, resetColor)
+  return doDescribeCapacity(providerName, sess)
+}
@@ -17,14 +17,14 @@ import (
 
 var cCache = make(awslib.ClusterCache,0)
 
-func doCreateCluster(sess *session.Session) (error) {
-  dup, err := cCache.Contains(currentCluster, sess);
+func doCreateCluster(clusterName string, sess *session.Session) (error) {
+  dup, err := cCache.Contains(clusterName, sess);
   if err != nil { return err }
-  if !dup {
-    return fmt.Errorf("Duplicate cluster: %s already exists.", currentCluster)
+  if dup {
+    return fmt.Errorf("Duplicate cluster: %s already exists.", clusterName)
   }
 
-  cluster, err := awslib.CreateCluster(currentCluster, sess)
+  cluster, err := awslib.CreateCluster(clusterName, sess)
   if err == nil {
     cCache.Update(sess)
     printCluster(cluster)
@@ -32,8 +32,9 @@ func doCreateCluster(sess *session.Session) (error) {
   return err
 }
 
-func doDeleteCluster(sess *session.Session) (error) {
-  cluster, err := awslib.DeleteCluster(currentCluster, sess)
+func doDeleteCluster(clusterName string, force bool, sess *session.Session) (error) {
+  if ok, err := confirmDestructive(fmt.Sprintf("Delete cluster %s", clusterName), clusterName, clusterName, force); !ok { return err }
+  cluster, err := awslib.DeleteCluster(clusterName, sess)
   if err == nil {
     cCache.Update(sess)
     printCluster(cluster)
@@ -62,7 +63,14 @@ func doListClusters(sess *session.Session) (error) {
   return nil
 }
 
-func doDescribeCluster(sess *session.Session) (error) {
+func doDescribeCluster(window time.Duration, sess *session.Session) (error) {
+
+  c, err := describeCluster(currentCluster, sess)
+  if err != nil { return err }
+  fmt.Printf("%sCluster \"%s\" capacity providers: %s%s\n", titleColor, currentCluster,
+    collectStringPointers(c.CapacityProviders), resetColor)
+  printCapacityProviderStrategy(c.DefaultCapacityProviderStrategy)
+  fmt.Println()
 
   cimap, _, err := awslib.GetContainerMaps(currentCluster, sess)
   if err != nil { return err }
@@ -85,6 +93,8 @@ func doDescribeCluster(sess *session.Session) (error) {
     resetColor)
   w.Flush()
 
+  printClusterUtilization(currentCluster, window, sess)
+
   imap, err := awslib.DescribeEC2Instances(cimap, sess)
   if err != nil { return err }
 
@@ -0,0 +1,274 @@
+package interactive
+
+import (
+  "sort"
+  "strings"
+  "sync"
+  "time"
+  "github.com/alecthomas/kingpin"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const completionCacheTTL = time.Minute
+
+// Completes commands and flags from the kingpin model of interApp,
+// and argument values by argument name from AWS.
+type pilotCompleter struct {
+  app *kingpin.Application
+  cache *completionCache
+}
+
+func newPilotCompleter(app *kingpin.Application) (*pilotCompleter) {
+  return &pilotCompleter{app: app, cache: newCompletionCache()}
+}
+
+// What the completer, on readline's goroutine, sees of the state commands change.
+// The prompt loop publishes a copy before reading each line.
+type completionState struct {
+  cluster string
+  aliases map[string]string
+  clusters []string
+}
+
+var (
+  completionMu sync.Mutex
+  completionSnapshot = completionState{aliases: map[string]string{}}
+)
+
+func publishCompletionState() {
+  s := completionState{cluster: currentCluster, aliases: make(map[string]string)}
+  for name, expansion := range getAliases() { s.aliases[name] = expansion }
+  for name := range cCache { s.clusters = append(s.clusters, name) }
+  completionMu.Lock()
+  completionSnapshot = s
+  completionMu.Unlock()
+}
+
+func publishedCompletionState() (completionState) {
+  completionMu.Lock()
+  defer completionMu.Unlock()
+  return completionSnapshot
+}
+
+// readline's AutoCompleter: the candidates are what to add to the word being typed,
+// the length is how much of the word has been typed.
+func (pc *pilotCompleter) Do(line []rune, pos int) ([][]rune, int) {
+  text := string(line[:pos])
+  state := publishedCompletionState()
+  // Mid quote doesn't tokenize, but is still worth completing.
+  fields, err := tokenize(text)
+  if err != nil { fields = strings.Fields(text) }
+  word := ""
+  if len(fields) > 0 && !strings.HasSuffix(text, " ") {
+    word = fields[len(fields)-1]
+    fields = fields[:len(fields)-1]
+  }
+
+  var candidates []string
+  if len(fields) == 0 {
+    candidates = pc.candidates(fields, word, state)
+    for name := range state.aliases { candidates = append(candidates, name) }
+  } else {
+    if expanded, err := expandAlias(fields, state.aliases); err == nil { fields = expanded }
+    candidates = pc.candidates(fields, word, state)
+  }
+  sort.Strings(candidates)
+  completions := make([][]rune, 0, len(candidates))
+  for _, c := range candidates {
+    if strings.HasPrefix(c, word) {
+      completions = append(completions, []rune(c[len(word):] + " "))
+    }
+  }
+  return completions, len([]rune(word))
+}
+
+func (pc *pilotCompleter) candidates(fields []string, word string, state completionState) ([]string) {
+  model := pc.app.Model()
+  commands := model.Commands
+  flags := model.Flags
+  args := []*kingpin.ArgModel{}
+  argIndex := 0
+
+  descend := func(c *kingpin.CmdModel) {
+    commands, args, argIndex = c.Commands, c.Args, 0
+    flags = append(flags, c.Flags...)
+  }
+  for i := 0; i < len(fields); i++ {
+    f := fields[i]
+    if strings.HasPrefix(f, "-") {
+      // Skip the value of flags that take one, unless it was given with =.
+      if fm := findFlag(flags, f); fm != nil && !fm.IsBoolFlag() && !strings.Contains(f, "=") { i++ }
+      continue
+    }
+    if len(commands) > 0 {
+      if next := findCommand(commands, f); next != nil {
+        descend(next)
+        continue
+      }
+      // Not a subcommand, so it's an argument to the default subcommand if there is one.
+      if def := defaultCommand(commands); def != nil { descend(def) }
+    }
+    argIndex++
+  }
+
+  if strings.HasPrefix(word, "-") {
+    names := make([]string, 0, len(flags))
+    for _, fm := range flags {
+      if !fm.Hidden { names = append(names, "--" + fm.Name) }
+    }
+    return names
+  }
+
+  names := make([]string, 0)
+  if argIndex == 0 {
+    for _, c := range commands {
+      if !c.Hidden { names = append(names, c.Name) }
+    }
+    if def := defaultCommand(commands); def != nil && len(def.Args) > 0 {
+      names = append(names, pc.cache.values(def.Args[0].Name, state)...)
+    }
+  }
+  if len(commands) == 0 && argIndex < len(args) {
+    names = append(names, pc.cache.values(args[argIndex].Name, state)...)
+  }
+  return names
+}
+
+func findCommand(commands []*kingpin.CmdModel, name string) (*kingpin.CmdModel) {
+  for _, c := range commands {
+    if c.Name == name { return c }
+    for _, a := range c.Aliases {
+      if a == name { return c }
+    }
+  }
+  return nil
+}
+
+func defaultCommand(commands []*kingpin.CmdModel) (*kingpin.CmdModel) {
+  for _, c := range commands {
+    if c.Default { return c }
+  }
+  return nil
+}
+
+func findFlag(flags []*kingpin.FlagModel, f string) (*kingpin.FlagModel) {
+  name := strings.SplitN(strings.TrimLeft(f, "-"), "=", 2)[0]
+  for _, fm := range flags {
+    if fm.Name == name || (len(name) == 1 && fm.Short == rune(name[0])) { return fm }
+  }
+  return nil
+}
+
+// Argument values from AWS, kept for a minute so completing doesn't wait on AWS every tab.
+type completionCache struct {
+  mu sync.Mutex
+  entries map[string]completionEntry
+}
+
+type completionEntry struct {
+  values []string
+  fetched time.Time
+}
+
+func newCompletionCache() (*completionCache) {
+  return &completionCache{entries: make(map[string]completionEntry)}
+}
+
+func (cc *completionCache) values(argName string, state completionState) ([]string) {
+  sess := currentSession
+  if sess == nil { return []string{} }
+  var fetch func(*session.Session, string) ([]string, error)
+  key := argName
+  switch argName {
+  case "cluster-name":
+    if len(state.clusters) > 0 { return state.clusters }
+    fetch = completeClusters
+  case "service-name": fetch = completeServices; key += ":" + state.cluster
+  case "task-arn": fetch = completeTasks; key += ":" + state.cluster
+  case "instance-arn": fetch = completeContainerInstances; key += ":" + state.cluster
+  case "provider-name": fetch = completeCapacityProviders; key += ":" + state.cluster
+  case "task-definition", "task-definition-arn": fetch = completeTaskDefinitions
+  case "repository": fetch = completeRepositories
+  default: return []string{}
+  }
+
+  cc.mu.Lock()
+  e, ok := cc.entries[key]
+  cc.mu.Unlock()
+  if ok && time.Since(e.fetched) < completionCacheTTL { return e.values }
+
+  values, err := fetch(sess, state.cluster)
+  if err != nil { return []string{} }
+  cc.mu.Lock()
+  cc.entries[key] = completionEntry{values: values, fetched: time.Now()}
+  cc.mu.Unlock()
+  return values
+}
+
+// Only reached when the prompt has no clusters cached; cCache is left to the prompt's goroutine.
+func completeClusters(sess *session.Session, clusterName string) ([]string, error) {
+  clusters, err := awslib.GetAllClusterDescriptions(sess)
+  if err != nil { return nil, err }
+  names := make([]string, 0, len(clusters))
+  for _, c := range clusters { names = append(names, aws.StringValue(c.ClusterName)) }
+  return names, nil
+}
+
+func completeServices(sess *session.Session, clusterName string) ([]string, error) {
+  services, _, err := awslib.DescribeServices(clusterName, sess)
+  if err != nil { return nil, err }
+  names := make([]string, 0, len(services))
+  for _, s := range services { names = append(names, aws.StringValue(s.ServiceName)) }
+  return names, nil
+}
+
+func completeTasks(sess *session.Session, clusterName string) ([]string, error) {
+  arns := make([]string, 0)
+  err := ecs.New(sess).ListTasksPages(&ecs.ListTasksInput{Cluster: aws.String(clusterName)},
+    func(page *ecs.ListTasksOutput, last bool) bool {
+      for _, arn := range page.TaskArns { arns = append(arns, awslib.ShortArnString(arn)) }
+      return true
+    })
+  return arns, err
+}
+
+func completeContainerInstances(sess *session.Session, clusterName string) ([]string, error) {
+  arns := make([]string, 0)
+  err := ecs.New(sess).ListContainerInstancesPages(&ecs.ListContainerInstancesInput{Cluster: aws.String(clusterName)},
+    func(page *ecs.ListContainerInstancesOutput, last bool) bool {
+      for _, arn := range page.ContainerInstanceArns { arns = append(arns, awslib.ShortArnString(arn)) }
+      return true
+    })
+  return arns, err
+}
+
+func completeCapacityProviders(sess *session.Session, clusterName string) ([]string, error) {
+  c, err := describeCluster(clusterName, sess)
+  if err != nil { return nil, err }
+  return aws.StringValueSlice(c.CapacityProviders), nil
+}
+
+// Families and family:revision.
+func completeTaskDefinitions(sess *session.Session, clusterName string) ([]string, error) {
+  families, err := awslib.ListTaskDefinitionFamilies(sess)
+  if err != nil { return nil, err }
+  arns, err := awslib.ListTaskDefinitions(sess)
+  if err != nil { return nil, err }
+  names := make([]string, 0, len(families) + len(arns))
+  names = append(names, aws.StringValueSlice(families)...)
+  for _, arn := range arns { names = append(names, awslib.ShortArnString(arn)) }
+  return names, nil
+}
+
+func completeRepositories(sess *session.Session, clusterName string) ([]string, error) {
+  repos, err := awslib.GetRepositories(sess)
+  if err != nil { return nil, err }
+  names := make([]string, 0, len(repos))
+  for _, r := range repos { names = append(names, aws.StringValue(r.RepositoryName)) }
+  return names, nil
+}
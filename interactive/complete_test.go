@@ -0,0 +1,44 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/alecthomas/kingpin"
+  "github.com/stretchr/testify/assert"
+)
+
+func testCompleterApp() (*kingpin.Application) {
+  app := kingpin.New("", "test")
+  svc := app.Command("service", "")
+  list := svc.Command("list", "")
+  list.Flag("verbose", "").Bool()
+  list.Flag("window", "").String()
+  list.Arg("cluster-name", "").String()
+  svc.Command("describe", "").Arg("service-name", "").String()
+  deploy := svc.Command("deploy", "")
+  deploy.Command("start", "").Default().Arg("service-name", "").String()
+  deploy.Command("rollback", "")
+  return app
+}
+
+func completions(pc *pilotCompleter, line string) ([]string) {
+  cs, _ := pc.Do([]rune(line), len([]rune(line)))
+  s := make([]string, 0, len(cs))
+  for _, c := range cs { s = append(s, string(c)) }
+  return s
+}
+
+func TestCompleterCommandsAndFlags(t *testing.T) {
+  currentSession = nil // No AWS values.
+  aliases = map[string]string{"sv": "service"}
+  publishCompletionState()
+  pc := newPilotCompleter(testCompleterApp())
+  assert.Equal(t, []string{"rvice "}, completions(pc, "se"))
+  assert.Equal(t, []string{"deploy ", "describe ", "list "}, completions(pc, "service "))
+  assert.Equal(t, []string{"ploy ", "scribe "}, completions(pc, "service de"))
+  assert.Equal(t, []string{"help ", "verbose ", "window "}, completions(pc, "service list --"))
+  assert.Equal(t, []string{"rollback ", "start "}, completions(pc, "service deploy "))
+  assert.Empty(t, completions(pc, "service list --window 1h prod "))
+  assert.Equal(t, []string{"ervice ", "v "}, completions(pc, "s"), "Should complete aliases too.")
+  assert.Equal(t, []string{"deploy ", "describe ", "list "}, completions(pc, "sv "))
+  assert.Empty(t, completions(pc, `service list --window "1 h" prod `))
+}
@@ -0,0 +1,56 @@
+package interactive
+
+import (
+  "fmt"
+  "ecs-pilot/audit"
+  "io/ioutil"
+  "os"
+  "os/user"
+  "path/filepath"
+  "gopkg.in/yaml.v2"
+)
+
+const (
+  configDirName = ".ecs-pilot"
+  configFileName = "config.yaml"
+)
+
+// The user's ~/.ecs-pilot/config.yaml.
+type pilotConfig struct {
+  LaunchTemplates map[string]*launchTemplate `yaml:"launchTemplates"`
+  Aliases map[string]string `yaml:"aliases"` // eg. st: task status
+  ProtectedClusters []string `yaml:"protectedClusters"` // Names or patterns, eg. prod-*
+  Audit []*audit.SinkConfig `yaml:"audit"` // Besides ~/.ecs-pilot/audit.log, see package audit.
+}
+
+var loadedConfig *pilotConfig
+
+// The per user directory for ecs-pilot's files, created if it's not there.
+func configDir() (string, error) {
+  u, err := user.Current()
+  if err != nil { return "", fmt.Errorf("Can't find the current user's home directory: %s", err) }
+  dir := filepath.Join(u.HomeDir, configDirName)
+  if err = os.MkdirAll(dir, 0700); err != nil { return "", err }
+  return dir, nil
+}
+
+func configFilePath(name string) (string, error) {
+  dir, err := configDir()
+  if err != nil { return "", err }
+  return filepath.Join(dir, name), nil
+}
+
+// Read once, an empty config if there is no file.
+func getConfig() (*pilotConfig, error) {
+  if loadedConfig != nil { return loadedConfig, nil }
+  fn, err := configFilePath(configFileName)
+  if err != nil { return nil, err }
+  config := new(pilotConfig)
+  b, err := ioutil.ReadFile(fn)
+  if err != nil && !os.IsNotExist(err) { return nil, err }
+  if err == nil {
+    if err = yaml.Unmarshal(b, config); err != nil { return nil, fmt.Errorf("Can't read config %s: %s", fn, err) }
+  }
+  loadedConfig = config
+  return loadedConfig, nil
+}
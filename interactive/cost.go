@@ -0,0 +1,264 @@
+package interactive
+
+import (
+  "encoding/json"
+  "fmt"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+  "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ec2"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const hoursPerMonth = 730
+
+type costReport struct {
+  Cluster string `json:"cluster"`
+  Region string `json:"region"`
+  Instances []*instanceCost `json:"instances"`
+  Services []*costShare `json:"services"`
+  Tasks []*costShare `json:"tasks"` // Standalone tasks by task definition family.
+  Idle costAmount `json:"idle"`
+  Total costAmount `json:"total"`
+  FargateTasks int `json:"fargateTasks"` // Not on our instances, so not in the totals.
+}
+
+type costAmount struct {
+  Hourly float64 `json:"hourly"`
+  Monthly float64 `json:"monthly"`
+}
+
+func (c *costAmount) add(hourly float64) {
+  c.Hourly += hourly
+  c.Monthly = c.Hourly * hoursPerMonth
+}
+
+type instanceCost struct {
+  Ec2Id string `json:"ec2InstanceId"`
+  InstanceType string `json:"instanceType"`
+  Lifecycle string `json:"lifecycle"`
+  Priced bool `json:"priced"`
+  Tasks int `json:"tasks"`
+  Cost costAmount `json:"cost"`
+  Idle costAmount `json:"idle"`
+}
+
+type costShare struct {
+  Name string `json:"name"`
+  Tasks int `json:"tasks"`
+  Cost costAmount `json:"cost"`
+}
+
+// What a task has reserved on its instance.
+type taskReservation struct {
+  Cpu int64
+  Mem int64
+}
+
+// Splits an instance's hourly cost over its tasks by the average of their share of
+// the instance's registered CPU and memory. What's left over is idle.
+func allocateInstanceCost(hourly float64, registeredCpu, registeredMem int64, tasks []taskReservation) (shares []float64, idle float64) {
+  shares = make([]float64, len(tasks))
+  idle = hourly
+  for i, t := range tasks {
+    fractions, n := 0.0, 0.0
+    if registeredCpu > 0 { fractions += float64(t.Cpu) / float64(registeredCpu); n++ }
+    if registeredMem > 0 { fractions += float64(t.Mem) / float64(registeredMem); n++ }
+    if n == 0 { continue }
+    shares[i] = hourly * fractions / n
+    if shares[i] > idle { shares[i] = idle }
+    idle -= shares[i]
+  }
+  return shares, idle
+}
+
+func runningTasks(clusterName string, sess *session.Session) ([]*ecs.Task, error) {
+  arns := make([]*string, 0)
+  err := ecs.New(sess).ListTasksPages(&ecs.ListTasksInput{
+    Cluster: aws.String(clusterName),
+    DesiredStatus: aws.String(ecs.DesiredStatusRunning),
+  }, func(page *ecs.ListTasksOutput, last bool) bool {
+    arns = append(arns, page.TaskArns...)
+    return true
+  })
+  if err != nil || len(arns) == 0 { return []*ecs.Task{}, err }
+  return describeTasks(clusterName, arns, sess)
+}
+
+// Tasks only carry their sizes when the task definition sets them at the task level,
+// otherwise they come from the containers.
+func taskReservationFor(t *ecs.Task, tdCache map[string]*ecs.TaskDefinition, sess *session.Session) (taskReservation, error) {
+  r := taskReservation{}
+  cpu, cerr := strconv.ParseInt(aws.StringValue(t.Cpu), 10, 64)
+  mem, merr := strconv.ParseInt(aws.StringValue(t.Memory), 10, 64)
+  if cerr == nil && merr == nil {
+    r.Cpu, r.Mem = cpu, mem
+    return r, nil
+  }
+  tdArn := aws.StringValue(t.TaskDefinitionArn)
+  td, ok := tdCache[tdArn]
+  if !ok {
+    var err error
+    td, err = awslib.GetTaskDefinition(tdArn, sess)
+    if err != nil { return r, err }
+    tdCache[tdArn] = td
+  }
+  needs := taskFitNeeds(td)
+  r.Cpu, r.Mem = needs.Cpu, needs.Mem
+  if cerr == nil { r.Cpu = cpu }
+  if merr == nil { r.Mem = mem }
+  return r, nil
+}
+
+// Services by name, standalone tasks by task definition family.
+func taskCostKey(t *ecs.Task) (name string, isService bool) {
+  group := aws.StringValue(t.Group)
+  if strings.HasPrefix(group, serviceGroupPrefix) { return strings.TrimPrefix(group, serviceGroupPrefix), true }
+  family := awslib.ShortArnString(t.TaskDefinitionArn)
+  if i := strings.LastIndex(family, ":"); i > 0 { family = family[:i] }
+  return family, false
+}
+
+func makeCostReport(clusterName string, sess *session.Session) (*costReport, error) {
+  prices, err := loadPriceTable()
+  if err != nil { return nil, err }
+  ciMap, ec2Map, err := awslib.GetContainerMaps(clusterName, sess)
+  if err != nil { return nil, err }
+  tasks, err := runningTasks(clusterName, sess)
+  if err != nil { return nil, err }
+
+  report := &costReport{Cluster: clusterName, Region: aws.StringValue(sess.Config.Region)}
+  tasksByInstance := make(map[string][]*ecs.Task)
+  for _, t := range tasks {
+    if t.ContainerInstanceArn == nil {
+      report.FargateTasks++
+      continue
+    }
+    tasksByInstance[*t.ContainerInstanceArn] = append(tasksByInstance[*t.ContainerInstanceArn], t)
+  }
+
+  services := make(map[string]*costShare)
+  standalone := make(map[string]*costShare)
+  tdCache := make(map[string]*ecs.TaskDefinition)
+  for arn, ci := range ciMap {
+    if ci.Instance == nil { continue }
+    inst := ec2Map[aws.StringValue(ci.Instance.Ec2InstanceId)]
+    ic := &instanceCost{Ec2Id: aws.StringValue(ci.Instance.Ec2InstanceId), Lifecycle: "on-demand"}
+    spot := false
+    if inst != nil {
+      ic.InstanceType = aws.StringValue(inst.InstanceType)
+      spot = aws.StringValue(inst.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot
+      if spot { ic.Lifecycle = "spot" }
+    }
+    hourly, ok := prices.price(report.Region, ic.InstanceType, spot)
+    ic.Priced = ok
+    ic.Cost.add(hourly)
+    report.Total.add(hourly)
+    report.Instances = append(report.Instances, ic)
+
+    its := tasksByInstance[arn]
+    ic.Tasks = len(its)
+    reservations := make([]taskReservation, 0, len(its))
+    for _, t := range its {
+      r, err := taskReservationFor(t, tdCache, sess)
+      if err != nil { return nil, err }
+      reservations = append(reservations, r)
+    }
+    shares, idle := allocateInstanceCost(hourly, getCpu(ci.Instance.RegisteredResources),
+      getMemory(ci.Instance.RegisteredResources), reservations)
+    ic.Idle.add(idle)
+    report.Idle.add(idle)
+
+    for i, t := range its {
+      name, isService := taskCostKey(t)
+      m := standalone
+      if isService { m = services }
+      cs, ok := m[name]
+      if !ok {
+        cs = &costShare{Name: name}
+        m[name] = cs
+      }
+      cs.Tasks++
+      cs.Cost.add(shares[i])
+    }
+  }
+
+  sort.Slice(report.Instances, func(i, j int) bool { return report.Instances[i].Ec2Id < report.Instances[j].Ec2Id })
+  report.Services = sortedCostShares(services)
+  report.Tasks = sortedCostShares(standalone)
+  return report, nil
+}
+
+// Most expensive first.
+func sortedCostShares(m map[string]*costShare) ([]*costShare) {
+  shares := make([]*costShare, 0, len(m))
+  for _, cs := range m { shares = append(shares, cs) }
+  sort.Slice(shares, func(i, j int) bool {
+    if shares[i].Cost.Hourly == shares[j].Cost.Hourly { return shares[i].Name < shares[j].Name }
+    return shares[i].Cost.Hourly > shares[j].Cost.Hourly
+  })
+  return shares
+}
+
+func doClusterCost(clusterName string, asJson bool, sess *session.Session) (error) {
+  report, err := makeCostReport(clusterName, sess)
+  if err != nil { return err }
+
+  if asJson {
+    b, err := json.MarshalIndent(report, "", "  ")
+    if err != nil { return err }
+    fmt.Printf("%s\n", b)
+    return nil
+  }
+
+  fmt.Printf("%sCluster %s in %s: $%.2f/hour, $%.2f/month, of which idle $%.2f/hour, $%.2f/month.%s\n",
+    titleColor, clusterName, report.Region, report.Total.Hourly, report.Total.Monthly,
+    report.Idle.Hourly, report.Idle.Monthly, resetColor)
+
+  fmt.Printf("\n%sInstances:%s\n", titleColor, resetColor)
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sEC2\tType\tLifecycle\tTasks\t$/Hour\t$/Month\tIdle $/Hour\tIdle%%%s\n", titleColor, resetColor)
+  for _, ic := range report.Instances {
+    if !ic.Priced {
+      fmt.Fprintf(w, "%s%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s%s\n", warnColor, ic.Ec2Id, ic.InstanceType, ic.Lifecycle,
+        ic.Tasks, "no price", "-", "-", "-", resetColor)
+      continue
+    }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%d\t%.4f\t%.2f\t%.4f\t%.0f%%%s\n", nullColor, ic.Ec2Id, ic.InstanceType, ic.Lifecycle,
+      ic.Tasks, ic.Cost.Hourly, ic.Cost.Monthly, ic.Idle.Hourly, percent(ic.Idle.Hourly, ic.Cost.Hourly), resetColor)
+  }
+  w.Flush()
+
+  printCostShares("Services", report.Services, report.Total.Hourly)
+  printCostShares("Standalone Tasks", report.Tasks, report.Total.Hourly)
+
+  if report.FargateTasks > 0 {
+    fmt.Printf("\n%s%d Fargate tasks are not on the cluster's instances and aren't included.%s\n",
+      warnColor, report.FargateTasks, resetColor)
+  }
+  return nil
+}
+
+func printCostShares(title string, shares []*costShare, total float64) {
+  if len(shares) == 0 { return }
+  fmt.Printf("\n%s%s:%s\n", titleColor, title, resetColor)
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sName\tTasks\t$/Hour\t$/Month\tShare%s\n", titleColor, resetColor)
+  for _, cs := range shares {
+    fmt.Fprintf(w, "%s%s\t%d\t%.4f\t%.2f\t%.0f%%%s\n", nullColor, cs.Name, cs.Tasks,
+      cs.Cost.Hourly, cs.Cost.Monthly, percent(cs.Cost.Hourly, total), resetColor)
+  }
+  w.Flush()
+}
+
+func percent(part, whole float64) (float64) {
+  if whole == 0 { return 0 }
+  return 100 * part / whole
+}
@@ -0,0 +1,37 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestAllocateInstanceCost(t *testing.T) {
+  tasks := []taskReservation{
+    {Cpu: 512, Mem: 1024}, // A quarter of both.
+    {Cpu: 1024, Mem: 0}, // Half the CPU, none of the memory.
+  }
+  shares, idle := allocateInstanceCost(1.0, 2048, 4096, tasks)
+  assert.InDelta(t, 0.25, shares[0], 0.0001)
+  assert.InDelta(t, 0.25, shares[1], 0.0001)
+  assert.InDelta(t, 0.5, idle, 0.0001)
+
+  // Over reserved can't cost more than the instance.
+  shares, idle = allocateInstanceCost(1.0, 1024, 1024, []taskReservation{{Cpu: 1024, Mem: 1024}, {Cpu: 512, Mem: 512}})
+  assert.InDelta(t, 1.0, shares[0], 0.0001)
+  assert.InDelta(t, 0.0, shares[1], 0.0001)
+  assert.InDelta(t, 0.0, idle, 0.0001)
+}
+
+func TestPriceTable(t *testing.T) {
+  prices := make(priceTable)
+  prices.merge(priceTable{"us-east-1": {"t2.micro": {OnDemand: 0.0116, Spot: 0.0035}}})
+  prices.merge(priceTable{"us-east-1": {"t2.micro": {OnDemand: 0.02}}})
+  p, ok := prices.price("us-east-1", "t2.micro", false)
+  assert.True(t, ok)
+  assert.Equal(t, 0.02, p)
+  p, ok = prices.price("us-east-1", "t2.micro", true)
+  assert.True(t, ok, "Spot price should have been kept when only onDemand was updated.")
+  assert.Equal(t, 0.0035, p)
+  _, ok = prices.price("ap-south-1", "t2.micro", false)
+  assert.False(t, ok)
+}
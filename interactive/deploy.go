@@ -0,0 +1,283 @@
+package interactive
+
+import (
+  "encoding/json"
+  "fmt"
+  "os"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/codedeploy"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const (
+  rollingStrategy = "rolling"
+  blueGreenStrategy = "bluegreen"
+  deployPollInterval = 15 * time.Second
+)
+
+func deploymentControllerType(s *ecs.Service) (string) {
+  if s.DeploymentController == nil || s.DeploymentController.Type == nil {
+    return ecs.DeploymentControllerTypeEcs
+  }
+  return *s.DeploymentController.Type
+}
+
+func doDeployService(serviceName, taskDefinition, clusterName, strategy, appName, groupName string, sess *session.Session) (error) {
+  s, failures, err := awslib.DescribeService(serviceName, clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return err }
+
+  td, err := awslib.GetTaskDefinition(taskDefinition, sess)
+  if err != nil { return fmt.Errorf("Can't find task definition %s: %s", taskDefinition, err) }
+
+  controller := deploymentControllerType(s)
+  switch strategy {
+  case rollingStrategy:
+    if controller != ecs.DeploymentControllerTypeEcs {
+      return fmt.Errorf("Service %s uses the %s deployment controller, use --strategy %s", serviceName, controller, blueGreenStrategy)
+    }
+    return deployRolling(s, td, clusterName, sess)
+  case blueGreenStrategy:
+    if controller != ecs.DeploymentControllerTypeCodeDeploy {
+      return fmt.Errorf("Service %s uses the %s deployment controller, blue/green needs %s",
+        serviceName, controller, ecs.DeploymentControllerTypeCodeDeploy)
+    }
+    return deployBlueGreen(s, td, clusterName, appName, groupName, sess)
+  }
+  return fmt.Errorf("Unknown deployment strategy: %s", strategy)
+}
+
+func deployRolling(s *ecs.Service, td *ecs.TaskDefinition, clusterName string, sess *session.Session) (error) {
+  serviceName := *s.ServiceName
+  _, err := ecs.New(sess).UpdateService(&ecs.UpdateServiceInput{
+    Cluster: aws.String(clusterName),
+    Service: s.ServiceName,
+    TaskDefinition: td.TaskDefinitionArn,
+  })
+  if err != nil { return err }
+
+  start := time.Now()
+  fmt.Printf("%s%sRolling deployment of %s started for %s on cluster %s%s\n", successColor, nowString(),
+    awslib.ShortArnString(td.TaskDefinitionArn), serviceName, clusterName, resetColor)
+  fmt.Printf("%sWill notify when the service is stable.%s\n", infoColor, resetColor)
+  job := jobs.start(clusterName, fmt.Sprintf("deploy %s to %s", awslib.ShortArnString(td.TaskDefinitionArn), serviceName))
+  awslib.OnServiceStable(serviceName, clusterName, sess, func(err error) {
+    defer job.finish(err)
+    if job.cancelled() { return }
+    if err == nil {
+      fmt.Printf("\n%s%sDeployment complete (%s): %s on cluster %s%s\n",
+        successColor, nowString(), shortDurationString(time.Since(start)), serviceName, clusterName, resetColor)
+    } else {
+      fmt.Printf("\n%sError waiting for service to become stable after deployment: %s on cluster %s: %s%s\n",
+        failColor, serviceName, clusterName, err, resetColor)
+    }
+  })
+  return nil
+}
+
+func deployBlueGreen(s *ecs.Service, td *ecs.TaskDefinition, clusterName, appName, groupName string, sess *session.Session) (err error) {
+  serviceName := *s.ServiceName
+  if len(s.LoadBalancers) == 0 {
+    return fmt.Errorf("Service %s has no load balancer, blue/green needs one to shift traffic", serviceName)
+  }
+  if appName == "" || groupName == "" {
+    appName, groupName, err = findDeploymentGroup(serviceName, clusterName, sess)
+    if err != nil { return err }
+  }
+
+  content, err := appSpecContent(td, s.LoadBalancers[0])
+  if err != nil { return err }
+
+  resp, err := codedeploy.New(sess).CreateDeployment(&codedeploy.CreateDeploymentInput{
+    ApplicationName: aws.String(appName),
+    DeploymentGroupName: aws.String(groupName),
+    Description: aws.String(fmt.Sprintf("ecs-pilot deploy of %s", awslib.ShortArnString(td.TaskDefinitionArn))),
+    Revision: &codedeploy.RevisionLocation{
+      RevisionType: aws.String(codedeploy.RevisionLocationTypeAppSpecContent),
+      AppSpecContent: &codedeploy.AppSpecContent{Content: aws.String(content)},
+    },
+  })
+  if err != nil { return fmt.Errorf("Failed to create CodeDeploy deployment for %s: %s", serviceName, err) }
+
+  fmt.Printf("%s%sBlue/green deployment %s started for %s on cluster %s%s\n", successColor, nowString(),
+    *resp.DeploymentId, serviceName, clusterName, resetColor)
+  fmt.Printf("%sApplication: %s, Deployment Group: %s. Traffic shift progress to follow....%s\n",
+    infoColor, appName, groupName, resetColor)
+  go followBlueGreenDeployment(*resp.DeploymentId, serviceName, clusterName, sess)
+  return nil
+}
+
+// The AppSpec CodeDeploy needs to replace the task set behind the service's load balancer.
+func appSpecContent(td *ecs.TaskDefinition, lb *ecs.LoadBalancer) (string, error) {
+  appSpec := map[string]interface{}{
+    "version": "0.0", // The only version CodeDeploy takes for ECS.
+    "Resources": []interface{}{
+      map[string]interface{}{
+        "TargetService": map[string]interface{}{
+          "Type": "AWS::ECS::Service",
+          "Properties": map[string]interface{}{
+            "TaskDefinition": *td.TaskDefinitionArn,
+            "LoadBalancerInfo": map[string]interface{}{
+              "ContainerName": *lb.ContainerName,
+              "ContainerPort": *lb.ContainerPort,
+            },
+          },
+        },
+      },
+    },
+  }
+  b, err := json.Marshal(appSpec)
+  return string(b), err
+}
+
+// Look through the CodeDeploy applications for the deployment group that manages this service.
+func findDeploymentGroup(serviceName, clusterName string, sess *session.Session) (appName, groupName string, err error) {
+  svc := codedeploy.New(sess)
+  apps := make([]*string, 0)
+  err = svc.ListApplicationsPages(&codedeploy.ListApplicationsInput{}, func(page *codedeploy.ListApplicationsOutput, last bool) bool {
+    apps = append(apps, page.Applications...)
+    return true
+  })
+  if err != nil { return "", "", err }
+
+  for _, app := range apps {
+    groups, err := svc.ListDeploymentGroups(&codedeploy.ListDeploymentGroupsInput{ApplicationName: app})
+    if err != nil { return "", "", err }
+    if len(groups.DeploymentGroups) == 0 { continue }
+    infos, err := svc.BatchGetDeploymentGroups(&codedeploy.BatchGetDeploymentGroupsInput{
+      ApplicationName: app,
+      DeploymentGroupNames: groups.DeploymentGroups,
+    })
+    if err != nil { return "", "", err }
+    for _, dg := range infos.DeploymentGroupsInfo {
+      for _, es := range dg.EcsServices {
+        if aws.StringValue(es.ServiceName) == serviceName && aws.StringValue(es.ClusterName) == clusterName {
+          return *app, *dg.DeploymentGroupName, nil
+        }
+      }
+    }
+  }
+  return "", "", fmt.Errorf("No CodeDeploy deployment group found for %s on cluster %s", serviceName, clusterName)
+}
+
+// Polls the deployment and the service's task sets, printing as traffic shifts.
+func followBlueGreenDeployment(deploymentId, serviceName, clusterName string, sess *session.Session) {
+  svc := codedeploy.New(sess)
+  start := time.Now()
+  lastProgress := ""
+  for {
+    time.Sleep(deployPollInterval)
+    resp, err := svc.GetDeployment(&codedeploy.GetDeploymentInput{DeploymentId: aws.String(deploymentId)})
+    if err != nil {
+      fmt.Printf("\n%sError following deployment %s: %s%s\n", failColor, deploymentId, err, resetColor)
+      return
+    }
+    d := resp.DeploymentInfo
+    status := aws.StringValue(d.Status)
+
+    progress := status
+    s, _, err := awslib.DescribeService(serviceName, clusterName, sess)
+    if err == nil {
+      for _, ts := range s.TaskSets {
+        scale := "-"
+        if ts.Scale != nil { scale = fmt.Sprintf("%.0f%%", aws.Float64Value(ts.Scale.Value)) }
+        progress += fmt.Sprintf(" %s:%s", aws.StringValue(ts.Status), scale)
+      }
+    }
+    if progress != lastProgress {
+      fmt.Printf("\n%s%sDeployment %s (%s): %s%s\n", infoColor, nowString(), deploymentId,
+        shortDurationString(time.Since(start)), progress, resetColor)
+      lastProgress = progress
+    }
+
+    switch status {
+    case codedeploy.DeploymentStatusSucceeded:
+      fmt.Printf("%s%sDeployment %s succeeded for %s on cluster %s%s\n", successColor, nowString(),
+        deploymentId, serviceName, clusterName, resetColor)
+      return
+    case codedeploy.DeploymentStatusFailed, codedeploy.DeploymentStatusStopped:
+      msg := ""
+      if d.ErrorInformation != nil { msg = aws.StringValue(d.ErrorInformation.Message) }
+      fmt.Printf("%s%sDeployment %s %s for %s on cluster %s: %s%s\n", failColor, nowString(),
+        deploymentId, status, serviceName, clusterName, msg, resetColor)
+      return
+    }
+  }
+}
+
+// Blue/green services stop the in flight CodeDeploy deployment and roll back to the
+// original task set. Rolling services go back to the task definition of the
+// deployment being replaced.
+func doRollbackService(serviceName, clusterName, appName, groupName string, sess *session.Session) (err error) {
+  s, failures, err := awslib.DescribeService(serviceName, clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return err }
+
+  if deploymentControllerType(s) == ecs.DeploymentControllerTypeEcs {
+    for _, d := range s.Deployments {
+      if aws.StringValue(d.Status) == "ACTIVE" {
+        td, err := awslib.GetTaskDefinition(*d.TaskDefinition, sess)
+        if err != nil { return err }
+        fmt.Printf("%sRolling back %s to %s%s\n", warnColor, serviceName, awslib.ShortArnString(d.TaskDefinition), resetColor)
+        return deployRolling(s, td, clusterName, sess)
+      }
+    }
+    return fmt.Errorf("No deployment in progress for %s, deploy the previous task definition instead", serviceName)
+  }
+
+  if appName == "" || groupName == "" {
+    appName, groupName, err = findDeploymentGroup(serviceName, clusterName, sess)
+    if err != nil { return err }
+  }
+  svc := codedeploy.New(sess)
+  resp, err := svc.ListDeployments(&codedeploy.ListDeploymentsInput{
+    ApplicationName: aws.String(appName),
+    DeploymentGroupName: aws.String(groupName),
+    IncludeOnlyStatuses: aws.StringSlice([]string{
+      codedeploy.DeploymentStatusCreated, codedeploy.DeploymentStatusQueued,
+      codedeploy.DeploymentStatusInProgress, codedeploy.DeploymentStatusReady,
+    }),
+  })
+  if err != nil { return err }
+  if len(resp.Deployments) == 0 {
+    return fmt.Errorf("No CodeDeploy deployment in progress for %s, deploy the previous task definition instead", serviceName)
+  }
+
+  for _, id := range resp.Deployments {
+    stop, err := svc.StopDeployment(&codedeploy.StopDeploymentInput{
+      DeploymentId: id,
+      AutoRollbackEnabled: aws.Bool(true),
+    })
+    if err != nil { return fmt.Errorf("Failed to stop deployment %s: %s", *id, err) }
+    fmt.Printf("%s%sRolling back deployment %s: %s%s\n", warnColor, nowString(), *id, aws.StringValue(stop.StatusMessage), resetColor)
+    go followBlueGreenDeployment(*id, serviceName, clusterName, sess)
+  }
+  return nil
+}
+
+func printTaskSets(taskSets []*ecs.TaskSet) {
+  fmt.Printf("\n%sTask Sets (%d)%s\n", titleColor, len(taskSets), resetColor)
+  if len(taskSets) == 0 {
+    fmt.Printf("There are no task sets for this service.\n")
+    return
+  }
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sId\tStatus\tTaskDefinition\tTraffic\tDesired\tRunning\tPending\tStability\tUpdated%s\n", titleColor, resetColor)
+  for _, ts := range taskSets {
+    scale := "<none>"
+    if ts.Scale != nil { scale = fmt.Sprintf("%.0f%%", aws.Float64Value(ts.Scale.Value)) }
+    updated := "<none>"
+    if ts.UpdatedAt != nil { updated = ts.UpdatedAt.Local().Format(time.RFC1123) }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s%s\n", nullColor,
+      aws.StringValue(ts.Id), aws.StringValue(ts.Status), awslib.ShortArnString(ts.TaskDefinition), scale,
+      aws.Int64Value(ts.ComputedDesiredCount), aws.Int64Value(ts.RunningCount), aws.Int64Value(ts.PendingCount),
+      aws.StringValue(ts.StabilityStatus), updated, resetColor)
+  }
+  w.Flush()
+}
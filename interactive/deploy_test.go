@@ -0,0 +1,39 @@
+package interactive
+
+import(
+  "encoding/json"
+  "testing"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestAppSpecContent(t *testing.T) {
+  td := &ecs.TaskDefinition{TaskDefinitionArn: aws.String("arn:aws:ecs:us-east-1:123456789012:task-definition/web:7")}
+  lb := &ecs.LoadBalancer{ContainerName: aws.String("web"), ContainerPort: aws.Int64(8080)}
+  content, err := appSpecContent(td, lb)
+  if !assert.NoError(t, err) { return }
+
+  var appSpec struct {
+    Version string `json:"version"`
+    Resources []map[string]struct {
+      Type string
+      Properties struct {
+        TaskDefinition string
+        LoadBalancerInfo struct {
+          ContainerName string
+          ContainerPort int64
+        }
+      }
+    }
+  }
+  if !assert.NoError(t, json.Unmarshal([]byte(content), &appSpec), "The version should be a string.") { return }
+  assert.Equal(t, "0.0", appSpec.Version)
+  if assert.Len(t, appSpec.Resources, 1) {
+    target := appSpec.Resources[0]["TargetService"]
+    assert.Equal(t, "AWS::ECS::Service", target.Type)
+    assert.Equal(t, *td.TaskDefinitionArn, target.Properties.TaskDefinition)
+    assert.Equal(t, "web", target.Properties.LoadBalancerInfo.ContainerName)
+    assert.Equal(t, int64(8080), target.Properties.LoadBalancerInfo.ContainerPort)
+  }
+}
@@ -0,0 +1,38 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "text/tabwriter"
+  "ecs-pilot/doctor"
+  "github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Which of ecs-pilot's features the current identity's policies allow, up to level.
+func doDoctorPermissions(level string, sess *session.Session) (error) {
+  principal, results, err := doctor.CheckPermissions(level, sess)
+  if err != nil { return err }
+
+  missing := 0
+  for _, r := range results {
+    if !r.Ok() { missing++ }
+  }
+  fmt.Printf("%s%s permissions for %s:%s\n", titleColor, strings.Title(level), principal, resetColor)
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sFeature\tLevel\tStatus\tMissing%s\n", titleColor, resetColor)
+  for _, r := range results {
+    if r.Ok() {
+      fmt.Fprintf(w, "%s%s\t%s\tok\t%s\n", successColor, r.Name, r.Level, resetColor)
+    } else {
+      fmt.Fprintf(w, "%s%s\t%s\tmissing\t%s%s\n", failColor, r.Name, r.Level, strings.Join(r.Missing, ", "), resetColor)
+    }
+  }
+  w.Flush()
+  if missing == 0 {
+    fmt.Printf("%sEverything at %s level is allowed.%s\n", successColor, level, resetColor)
+  } else {
+    fmt.Printf("%s%d of %d features are missing permissions.%s\n", warnColor, missing, len(results), resetColor)
+  }
+  return nil
+}
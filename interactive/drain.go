@@ -0,0 +1,197 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const (
+  drainPollInterval = 15 * time.Second
+  drainTimeout = 30 * time.Minute
+  serviceGroupPrefix = "service:"
+)
+
+func doDrainContainerInstance(sess *session.Session) (error) {
+  ciArn, err := awslib.LongArnString(interContainerArn, awslib.ContainerInstanceType, sess)
+  if err != nil { return err }
+  clusterName := currentCluster
+  return drainContainerInstance(clusterName, ciArn, sess, func(err error) {
+    if err != nil {
+      fmt.Printf("\n%sError draining container instance %s: %s%s\n", failColor, awslib.ShortArnString(&ciArn), err, resetColor)
+    }
+  })
+}
+
+// Sets the instance DRAINING and, in the background, waits for the service tasks to
+// leave and their services to be back to their desired counts before calling onDrained.
+// Standalone tasks are not moved by ECS, they're reported here as they will be lost
+// when the instance goes.
+func drainContainerInstance(clusterName, ciArn string, sess *session.Session, onDrained func(error)) (error) {
+  ecsSvc := ecs.New(sess)
+  resp, err := ecsSvc.UpdateContainerInstancesState(&ecs.UpdateContainerInstancesStateInput{
+    Cluster: aws.String(clusterName),
+    ContainerInstances: []*string{aws.String(ciArn)},
+    Status: aws.String(ecs.ContainerInstanceStatusDraining),
+  })
+  if err != nil { return err }
+  if len(resp.Failures) > 0 {
+    printFailures(resp.Failures)
+    return fmt.Errorf("Failed to set container instance %s to DRAINING", awslib.ShortArnString(&ciArn))
+  }
+
+  serviceTasks, standaloneTasks, err := instanceTasks(clusterName, ciArn, sess)
+  if err != nil { return err }
+  fmt.Printf("%s%sContainer instance %s is DRAINING on cluster %s with %d service tasks to reschedule.%s\n",
+    warnColor, nowString(), awslib.ShortArnString(&ciArn), clusterName, len(serviceTasks), resetColor)
+  if len(standaloneTasks) > 0 {
+    fmt.Printf("%sThese %d standalone tasks will not be rescheduled and will be lost when the instance terminates:%s\n",
+      failColor, len(standaloneTasks), resetColor)
+    printInstanceTasks(standaloneTasks)
+  }
+  fmt.Printf("%sWill notify when the service tasks are running elsewhere.%s\n", infoColor, resetColor)
+
+  job := jobs.start(clusterName, fmt.Sprintf("drain %s", awslib.ShortArnString(&ciArn)))
+  drained := func(err error) {
+    job.finish(err)
+    onDrained(err)
+  }
+  go func() {
+    start := time.Now()
+    remaining := len(serviceTasks)
+    for remaining > 0 {
+      if time.Since(start) > drainTimeout {
+        drained(fmt.Errorf("timed out after %s with %d service tasks still on the instance", drainTimeout, remaining))
+        return
+      }
+      select {
+      case <-job.ctx.Done():
+        onDrained(fmt.Errorf("stopped waiting for the drain with %d service tasks still on the instance", remaining))
+        return
+      case <-time.After(drainPollInterval):
+      }
+      st, _, err := instanceTasks(clusterName, ciArn, sess)
+      if err != nil {
+        drained(err)
+        return
+      }
+      if len(st) != remaining {
+        fmt.Printf("\n%s%s%d service tasks left on %s.%s\n", infoColor, nowString(), len(st), awslib.ShortArnString(&ciArn), resetColor)
+      }
+      remaining = len(st)
+    }
+
+    // Gone from here isn't yet running elsewhere.
+    services := taskServices(serviceTasks)
+    for len(services) > 0 {
+      unsettled, err := unsettledServices(clusterName, services, sess)
+      if err != nil {
+        drained(err)
+        return
+      }
+      if len(unsettled) == 0 { break }
+      if time.Since(start) > drainTimeout {
+        drained(fmt.Errorf("timed out after %s with services %s below their desired counts", drainTimeout, strings.Join(unsettled, ", ")))
+        return
+      }
+      select {
+      case <-job.ctx.Done():
+        onDrained(fmt.Errorf("stopped waiting for services %s to be rescheduled", strings.Join(unsettled, ", ")))
+        return
+      case <-time.After(drainPollInterval):
+      }
+    }
+    fmt.Printf("\n%s%sContainer instance drained (%s): %s on cluster %s%s\n", successColor, nowString(),
+      shortDurationString(time.Since(start)), awslib.ShortArnString(&ciArn), clusterName, resetColor)
+    drained(nil)
+  }()
+  return nil
+}
+
+// Running tasks on a container instance split into those started by a service and the rest.
+func instanceTasks(clusterName, ciArn string, sess *session.Session) (serviceTasks, standaloneTasks []*ecs.Task, err error) {
+  ecsSvc := ecs.New(sess)
+  arns := make([]*string, 0)
+  err = ecsSvc.ListTasksPages(&ecs.ListTasksInput{
+    Cluster: aws.String(clusterName),
+    ContainerInstance: aws.String(ciArn),
+    DesiredStatus: aws.String(ecs.DesiredStatusRunning),
+  }, func(page *ecs.ListTasksOutput, last bool) bool {
+    arns = append(arns, page.TaskArns...)
+    return true
+  })
+  if err != nil || len(arns) == 0 { return serviceTasks, standaloneTasks, err }
+
+  tasks, err := describeTasks(clusterName, arns, sess)
+  if err != nil { return nil, nil, err }
+  for _, t := range tasks {
+    if strings.HasPrefix(aws.StringValue(t.Group), serviceGroupPrefix) {
+      serviceTasks = append(serviceTasks, t)
+    } else {
+      standaloneTasks = append(standaloneTasks, t)
+    }
+  }
+  return serviceTasks, standaloneTasks, nil
+}
+
+// The names of the services tasks belong to, once each.
+func taskServices(tasks []*ecs.Task) ([]string) {
+  seen := make(map[string]bool)
+  names := make([]string, 0)
+  for _, t := range tasks {
+    name := strings.TrimPrefix(aws.StringValue(t.Group), serviceGroupPrefix)
+    if !seen[name] { names = append(names, name) }
+    seen[name] = true
+  }
+  return names
+}
+
+// Those of names not running as many tasks as they want, or with tasks pending.
+func unsettledServices(clusterName string, names []string, sess *session.Session) ([]string, error) {
+  services, failures, err := awslib.DescribeServices(clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return nil, err }
+  wanted := make(map[string]bool)
+  for _, name := range names { wanted[name] = true }
+  unsettled := make([]string, 0)
+  for _, s := range services {
+    if !wanted[aws.StringValue(s.ServiceName)] || aws.StringValue(s.Status) != "ACTIVE" { continue }
+    if aws.Int64Value(s.RunningCount) != aws.Int64Value(s.DesiredCount) || aws.Int64Value(s.PendingCount) > 0 {
+      unsettled = append(unsettled, aws.StringValue(s.ServiceName))
+    }
+  }
+  return unsettled, nil
+}
+
+// DescribeTasks takes at most 100 tasks at a time.
+func describeTasks(clusterName string, arns []*string, sess *session.Session) ([]*ecs.Task, error) {
+  ecsSvc := ecs.New(sess)
+  tasks := make([]*ecs.Task, 0, len(arns))
+  for i := 0; i < len(arns); i += 100 {
+    end := i + 100
+    if end > len(arns) { end = len(arns) }
+    resp, err := ecsSvc.DescribeTasks(&ecs.DescribeTasksInput{Cluster: aws.String(clusterName), Tasks: arns[i:end]})
+    if err != nil { return nil, err }
+    tasks = append(tasks, resp.Tasks...)
+  }
+  return tasks, nil
+}
+
+func printInstanceTasks(tasks []*ecs.Task) {
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sTask\tTask Definition\tContainers\tStarted By\tStatus%s\n", titleColor, resetColor)
+  for _, t := range tasks {
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s%s\n", nullColor, awslib.ShortArnString(t.TaskArn),
+      awslib.ShortArnString(t.TaskDefinitionArn), awslib.CollectContainerNames(t.Containers),
+      aws.StringValue(t.StartedBy), aws.StringValue(t.LastStatus), resetColor)
+  }
+  w.Flush()
+}
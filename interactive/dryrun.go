@@ -0,0 +1,82 @@
+package interactive
+
+import (
+  "fmt"
+  "reflect"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/awserr"
+  "github.com/aws/aws-sdk-go/aws/awsutil"
+  "github.com/aws/aws-sdk-go/aws/request"
+  "github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+  dryRunErrorCode = "EcsPilotDryRun"
+  ec2DryRunOperation = "DryRunOperation" // EC2's answer when the call would have worked.
+)
+
+// Set by --dry-run, or for a whole script by ecs-pilot run --dry-run.
+var (
+  dryRunArg bool
+  assumeDryRun bool
+)
+
+func dryRun() (bool) {
+  return dryRunArg || assumeDryRun
+}
+
+// Calls that only look go ahead, anything else is printed instead of sent.
+var readOnlyPrefixes = []string{"Describe", "List", "Get", "Filter", "Simulate"}
+
+func readOnlyOperation(name string) (bool) {
+  for _, p := range readOnlyPrefixes {
+    if strings.HasPrefix(name, p) { return true }
+  }
+  return false
+}
+
+// A copy of sess that prints the calls that would change something rather than
+// making them. Calls with a DryRun parameter, as many EC2 calls have, are sent
+// with it set so AWS checks the permissions without doing anything. Either way the
+// call fails with a dry run error, so a command stops at its first change: there's no
+// answer to hand back that the calls after it could safely use.
+func dryRunSession(sess *session.Session) (*session.Session) {
+  s := sess.Copy()
+  s.Handlers.Validate.PushFront(func(r *request.Request) {
+    if readOnlyOperation(r.Operation.Name) { return }
+    call := fmt.Sprintf("%s %s", strings.ToUpper(r.ClientInfo.ServiceName), r.Operation.Name)
+    fmt.Printf("%sDry run, would call %s:%s\n%s\n", emphColor, call, resetColor, awsutil.Prettify(r.Params))
+    if setDryRunParam(r.Params) {
+      fmt.Printf("%sSending %s with DryRun to check permissions.%s\n", infoColor, call, resetColor)
+      return
+    }
+    r.Error = awserr.New(dryRunErrorCode, fmt.Sprintf("dry run, %s not sent", call), nil)
+    r.Retryable = aws.Bool(false)
+  })
+  s.Handlers.UnmarshalError.PushBack(func(r *request.Request) {
+    if aerr, ok := r.Error.(awserr.Error); ok && aerr.Code() == ec2DryRunOperation {
+      call := fmt.Sprintf("%s %s", strings.ToUpper(r.ClientInfo.ServiceName), r.Operation.Name)
+      r.Error = awserr.New(dryRunErrorCode, fmt.Sprintf("dry run, %s would have been allowed", call), nil)
+    }
+  })
+  return s
+}
+
+// Sets a DryRun *bool field in params, if there is one.
+func setDryRunParam(params interface{}) (bool) {
+  v := reflect.ValueOf(params)
+  if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct { return false }
+  f := v.Elem().FieldByName("DryRun")
+  if !f.IsValid() || !f.CanSet() || f.Type() != reflect.TypeOf((*bool)(nil)) { return false }
+  f.Set(reflect.ValueOf(aws.Bool(true)))
+  return true
+}
+
+// The error a dry run stops a command with, awslib sometimes wraps errors so the code is
+// looked for in the message too.
+func isDryRunError(err error) (bool) {
+  if err == nil { return false }
+  if aerr, ok := err.(awserr.Error); ok { return aerr.Code() == dryRunErrorCode }
+  return strings.Contains(err.Error(), dryRunErrorCode)
+}
@@ -0,0 +1,38 @@
+package interactive
+
+import(
+  "errors"
+  "fmt"
+  "testing"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/awserr"
+  "github.com/aws/aws-sdk-go/service/ec2"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyOperation(t *testing.T) {
+  for _, op := range []string{"DescribeServices", "ListTasks", "GetCallerIdentity", "FilterLogEvents", "SimulatePrincipalPolicy"} {
+    assert.True(t, readOnlyOperation(op), op)
+  }
+  for _, op := range []string{"CreateService", "RunTask", "RunInstances", "TerminateInstances", "RegisterTaskDefinition"} {
+    assert.False(t, readOnlyOperation(op), op)
+  }
+}
+
+func TestSetDryRunParam(t *testing.T) {
+  run := &ec2.RunInstancesInput{ImageId: aws.String("ami-1")}
+  assert.True(t, setDryRunParam(run))
+  if assert.NotNil(t, run.DryRun) { assert.True(t, *run.DryRun) }
+
+  assert.False(t, setDryRunParam(&ecs.RunTaskInput{}), "ECS has no DryRun.")
+  assert.False(t, setDryRunParam(nil))
+}
+
+func TestIsDryRunError(t *testing.T) {
+  err := awserr.New(dryRunErrorCode, "dry run, ECS RunTask not sent", nil)
+  assert.True(t, isDryRunError(err))
+  assert.True(t, isDryRunError(fmt.Errorf("Failed to run task: %s", err)))
+  assert.False(t, isDryRunError(errors.New("AccessDenied")))
+  assert.False(t, isDryRunError(nil))
+}
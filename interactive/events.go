@@ -0,0 +1,213 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "os/signal"
+  "regexp"
+  "sort"
+  "strings"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const (
+  eventPollInterval = 10 * time.Second
+  serviceEventsShown = 10
+  steadyStateMessage = "has reached a steady state"
+)
+
+// Messages that mean something is wrong, and those worth a second look.
+var (
+  failEventPatterns = []string{"unable to place a task", "unhealthy", "failed", "error", "insufficient"}
+  warnEventPatterns = []string{"deregistered", "stopped", "draining", "deployment"}
+)
+
+// A service event in the timeline. Repeated steady state events are collapsed into the first,
+// with Last set to the time of the last one.
+type serviceEvent struct {
+  Service string
+  Id string
+  CreatedAt time.Time
+  Last time.Time
+  Message string
+  Repeats int
+}
+
+func isSteadyState(message string) (bool) {
+  return strings.Contains(message, steadyStateMessage)
+}
+
+func eventColor(message string) (string) {
+  m := strings.ToLower(message)
+  for _, p := range failEventPatterns {
+    if strings.Contains(m, p) { return failColor }
+  }
+  for _, p := range warnEventPatterns {
+    if strings.Contains(m, p) { return warnColor }
+  }
+  if isSteadyState(message) { return successColor }
+  return nullColor
+}
+
+// Events for the services, oldest first, with those older than since (if not zero) or
+// not matching grep (if not nil) dropped and runs of steady state events collapsed.
+func eventTimeline(services []*ecs.Service, since time.Time, grep *regexp.Regexp) ([]*serviceEvent) {
+  events := make([]*serviceEvent, 0)
+  for _, s := range services {
+    for _, e := range s.Events {
+      if e.CreatedAt == nil { continue }
+      if !since.IsZero() && e.CreatedAt.Before(since) { continue }
+      msg := aws.StringValue(e.Message)
+      if grep != nil && !grep.MatchString(msg) { continue }
+      events = append(events, &serviceEvent{Service: aws.StringValue(s.ServiceName), Id: aws.StringValue(e.Id),
+        CreatedAt: *e.CreatedAt, Last: *e.CreatedAt, Message: msg})
+    }
+  }
+  sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+
+  collapsed := make([]*serviceEvent, 0, len(events))
+  last := make(map[string]*serviceEvent) // Last event kept for each service.
+  for _, e := range events {
+    if prev := last[e.Service]; prev != nil && isSteadyState(prev.Message) && isSteadyState(e.Message) {
+      prev.Repeats++
+      prev.Last = e.CreatedAt
+      continue
+    }
+    collapsed = append(collapsed, e)
+    last[e.Service] = e
+  }
+  return collapsed
+}
+
+func printEventTimeline(events []*serviceEvent, showService bool) {
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  if showService {
+    fmt.Fprintf(w, "%sTime\tService\tMessage%s\n", titleColor, resetColor)
+  } else {
+    fmt.Fprintf(w, "%sTime\tMessage%s\n", titleColor, resetColor)
+  }
+  for _, e := range events {
+    msg := e.Message
+    if e.Repeats > 0 {
+      msg += fmt.Sprintf(" (%d more times, last %s)", e.Repeats, e.Last.Local().Format(humanTimeFormat))
+    }
+    if showService {
+      fmt.Fprintf(w, "%s%s\t%s\t%s%s\n", eventColor(e.Message), e.CreatedAt.Local().Format(humanTimeFormat), e.Service, msg, resetColor)
+    } else {
+      fmt.Fprintf(w, "%s%s\t%s%s\n", eventColor(e.Message), e.CreatedAt.Local().Format(humanTimeFormat), msg, resetColor)
+    }
+  }
+  w.Flush()
+}
+
+// The most recent events for printService.
+func printRecentServiceEvents(s *ecs.Service) {
+  events := eventTimeline([]*ecs.Service{s}, time.Time{}, nil)
+  fmt.Printf("\n%sService Events (%d)%s\n", titleColor, len(s.Events), resetColor)
+  if len(events) == 0 {
+    fmt.Printf("There are no service events for this service.\n")
+    return
+  }
+  if len(events) > serviceEventsShown {
+    fmt.Printf("Showing the last %d, use service events for the rest.\n", serviceEventsShown)
+    events = events[len(events)-serviceEventsShown:]
+  }
+  printEventTimeline(events, false)
+}
+
+func eventServices(serviceName, clusterName string, sess *session.Session) ([]*ecs.Service, error) {
+  if serviceName == "" {
+    services, failures, err := awslib.DescribeServices(clusterName, sess)
+    if len(failures) > 0 { printFailures(failures) }
+    return services, err
+  }
+  s, failures, err := awslib.DescribeService(serviceName, clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return nil, err }
+  return []*ecs.Service{s}, nil
+}
+
+// Events for a service, or all the cluster's services if serviceName is empty.
+// Following polls for new events until interrupted.
+func doServiceEvents(serviceName, clusterName string, since time.Duration, grep string, follow bool, sess *session.Session) (error) {
+  var re *regexp.Regexp
+  if grep != "" {
+    var err error
+    if re, err = regexp.Compile("(?i)" + grep); err != nil { return fmt.Errorf("Bad --grep pattern: %s", err) }
+  }
+  var start time.Time
+  if since > 0 { start = time.Now().Add(-since) }
+
+  services, err := eventServices(serviceName, clusterName, sess)
+  if err != nil { return err }
+  events := eventTimeline(services, start, re)
+  allServices := serviceName == ""
+  if allServices {
+    fmt.Printf("%sEvents for %d services on cluster %s.%s\n", titleColor, len(services), clusterName, resetColor)
+  } else {
+    fmt.Printf("%sEvents for service %s on cluster %s.%s\n", titleColor, serviceName, clusterName, resetColor)
+  }
+  if len(events) == 0 {
+    fmt.Printf("No matching events.\n")
+  } else {
+    printEventTimeline(events, allServices)
+  }
+  if !follow { return nil }
+
+  // ECS returns the latest 100 events each time, so remember what we've shown.
+  seen := make(map[string]bool)
+  steady := make(map[string]bool) // Last event shown for a service was a steady state.
+  for _, s := range services {
+    for _, e := range s.Events { seen[aws.StringValue(e.Id)] = true }
+  }
+  for _, e := range events { steady[e.Service] = isSteadyState(e.Message) }
+
+  interrupt := make(chan os.Signal, 1)
+  signal.Notify(interrupt, os.Interrupt)
+  defer signal.Stop(interrupt)
+  fmt.Printf("%sFollowing, ^C to stop.%s\n", infoColor, resetColor)
+  ticker := time.NewTicker(eventPollInterval)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-interrupt:
+      fmt.Println()
+      return nil
+    case <-ticker.C:
+    }
+    services, err = eventServices(serviceName, clusterName, sess)
+    if err != nil {
+      fmt.Printf("%sError getting events: %s%s\n", failColor, err, resetColor)
+      continue
+    }
+    newServices := make([]*ecs.Service, 0, len(services))
+    for _, s := range services {
+      ns := &ecs.Service{ServiceName: s.ServiceName}
+      for _, e := range s.Events {
+        if !seen[aws.StringValue(e.Id)] {
+          seen[aws.StringValue(e.Id)] = true
+          ns.Events = append(ns.Events, e)
+        }
+      }
+      newServices = append(newServices, ns)
+    }
+    fresh := make([]*serviceEvent, 0)
+    for _, e := range eventTimeline(newServices, time.Time{}, re) {
+      if isSteadyState(e.Message) && steady[e.Service] { continue }
+      steady[e.Service] = isSteadyState(e.Message)
+      fresh = append(fresh, e)
+    }
+    for _, e := range fresh {
+      name := ""
+      if allServices { name = e.Service + ": " }
+      fmt.Printf("%s%s %s%s%s\n", eventColor(e.Message), e.CreatedAt.Local().Format(humanTimeFormat), name, e.Message, resetColor)
+    }
+  }
+}
@@ -0,0 +1,45 @@
+package interactive
+
+import(
+  "regexp"
+  "testing"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func testServiceEvent(id string, at time.Time, message string) (*ecs.ServiceEvent) {
+  return &ecs.ServiceEvent{Id: aws.String(id), CreatedAt: aws.Time(at), Message: aws.String(message)}
+}
+
+func TestEventTimeline(t *testing.T) {
+  now := time.Now()
+  web := &ecs.Service{ServiceName: aws.String("web"), Events: []*ecs.ServiceEvent{
+    // ECS returns newest first.
+    testServiceEvent("w4", now.Add(-1 * time.Minute), "(service web) has reached a steady state."),
+    testServiceEvent("w3", now.Add(-2 * time.Minute), "(service web) has reached a steady state."),
+    testServiceEvent("w2", now.Add(-3 * time.Minute), "(service web) has reached a steady state."),
+    testServiceEvent("w1", now.Add(-10 * time.Minute), "(service web) was unable to place a task."),
+  }}
+  api := &ecs.Service{ServiceName: aws.String("api"), Events: []*ecs.ServiceEvent{
+    testServiceEvent("a1", now.Add(-5 * time.Minute), "(service api) has started 1 tasks."),
+  }}
+
+  events := eventTimeline([]*ecs.Service{web, api}, time.Time{}, nil)
+  if assert.Len(t, events, 3) {
+    assert.Equal(t, "w1", events[0].Id, "Should be oldest first.")
+    assert.Equal(t, "a1", events[1].Id, "Should interleave services by time.")
+    assert.Equal(t, "w2", events[2].Id)
+    assert.Equal(t, 2, events[2].Repeats, "Should collapse repeated steady states.")
+    assert.Equal(t, now.Add(-1 * time.Minute), events[2].Last)
+  }
+
+  events = eventTimeline([]*ecs.Service{web, api}, now.Add(-6 * time.Minute), nil)
+  assert.Len(t, events, 2)
+
+  events = eventTimeline([]*ecs.Service{web, api}, time.Time{}, regexp.MustCompile("(?i)UNABLE"))
+  if assert.Len(t, events, 1) {
+    assert.Equal(t, failColor, eventColor(events[0].Message))
+  }
+}
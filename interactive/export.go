@@ -0,0 +1,110 @@
+package interactive
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+// Exported specs are written as <service>.service.json and <family>.task.json
+// so that a directory of them can be committed and later applied.
+const (
+  serviceSpecSuffix = ".service.json"
+  taskSpecSuffix = ".task.json"
+)
+
+func doExportService(serviceName, clusterName, outDir string, sess *session.Session) (error) {
+  s, failures, err := awslib.DescribeService(serviceName, clusterName, sess)
+  if len(failures) > 0 {
+    fmt.Printf("%sFailures in describing service.%s\n", failColor, resetColor)
+    printFailures(failures)
+  }
+  if err != nil { return err }
+
+  td, err := awslib.GetTaskDefinition(*s.TaskDefinition, sess)
+  if err != nil { return fmt.Errorf("Can't get task definition %s: %s", *s.TaskDefinition, err) }
+
+  if err = os.MkdirAll(outDir, 0755); err != nil { return err }
+
+  tdFile := filepath.Join(outDir, *td.Family + taskSpecSuffix)
+  if err = writeSpec(tdFile, taskDefinitionSpec(td)); err != nil { return err }
+
+  sFile := filepath.Join(outDir, *s.ServiceName + serviceSpecSuffix)
+  if err = writeSpec(sFile, serviceSpec(s, td)); err != nil { return err }
+
+  fmt.Printf("%sExported service %s on cluster %s.%s\n", successColor, serviceName, clusterName, resetColor)
+  fmt.Printf("\tService: %s\n", sFile)
+  fmt.Printf("\tTask Definition: %s\n", tdFile)
+  return nil
+}
+
+// Only the fields that can be handed back to RegisterTaskDefinition are copied.
+// This leaves out the ARN, revision, status, requiresAttributes and compatibilities.
+func taskDefinitionSpec(td *ecs.TaskDefinition) (*ecs.RegisterTaskDefinitionInput) {
+  return &ecs.RegisterTaskDefinitionInput{
+    ContainerDefinitions: td.ContainerDefinitions,
+    Cpu: td.Cpu,
+    ExecutionRoleArn: td.ExecutionRoleArn,
+    Family: td.Family,
+    IpcMode: td.IpcMode,
+    Memory: td.Memory,
+    NetworkMode: td.NetworkMode,
+    PidMode: td.PidMode,
+    PlacementConstraints: td.PlacementConstraints,
+    ProxyConfiguration: td.ProxyConfiguration,
+    RequiresCompatibilities: td.RequiresCompatibilities,
+    TaskRoleArn: td.TaskRoleArn,
+    Volumes: td.Volumes,
+  }
+}
+
+// The service spec points at the task definition family, not a revision,
+// so applying it picks up whatever was registered from the matching task spec.
+// Cluster is left out so the spec can be applied to any cluster.
+func serviceSpec(s *ecs.Service, td *ecs.TaskDefinition) (*ecs.CreateServiceInput) {
+  spec := &ecs.CreateServiceInput{
+    ServiceName: s.ServiceName,
+    TaskDefinition: td.Family,
+    DesiredCount: s.DesiredCount,
+    DeploymentConfiguration: s.DeploymentConfiguration,
+    DeploymentController: s.DeploymentController,
+    HealthCheckGracePeriodSeconds: s.HealthCheckGracePeriodSeconds,
+    LaunchType: s.LaunchType,
+    CapacityProviderStrategy: s.CapacityProviderStrategy,
+    PlatformVersion: s.PlatformVersion,
+    NetworkConfiguration: s.NetworkConfiguration,
+    SchedulingStrategy: s.SchedulingStrategy,
+    ServiceRegistries: s.ServiceRegistries,
+    PlacementConstraints: s.PlacementConstraints,
+    PlacementStrategy: s.PlacementStrategy,
+  }
+  if len(s.LoadBalancers) > 0 {
+    spec.LoadBalancers = s.LoadBalancers
+    // AWS fills in the service linked role, which can't be passed back in.
+    if s.RoleArn != nil && !strings.Contains(*s.RoleArn, "aws-service-role") {
+      spec.Role = aws.String(*s.RoleArn)
+    }
+  }
+  return spec
+}
+
+// Specs are written in the same JSON shape the AWS CLI uses (--cli-input-json).
+func writeSpec(fileName string, spec interface{}) (error) {
+  b, err := jsonutil.BuildJSON(spec)
+  if err != nil { return fmt.Errorf("Couldn't marshall JSON for %s: %s", fileName, err) }
+  var out bytes.Buffer
+  if err = json.Indent(&out, b, "", "  "); err != nil { return err }
+  out.WriteString("\n")
+  return ioutil.WriteFile(fileName, out.Bytes(), 0644)
+}
@@ -0,0 +1,182 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+  "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+// What one copy of a task takes from a container instance.
+type fitNeeds struct {
+  Cpu int64
+  Mem int64
+  Ports []string // protocol/port, eg. tcp/80
+}
+
+// A container instance's remaining resources as the simulation uses them up.
+type fitInstance struct {
+  Arn string
+  Ec2Id string
+  Unavailable string // Why nothing can be placed here at all, eg. the instance is DRAINING.
+  Cpu int64
+  Mem int64
+  Ports map[string]bool
+  StartCpu int64
+  StartMem int64
+  Placed int
+}
+
+func taskFitNeeds(td *ecs.TaskDefinition) (needs fitNeeds) {
+  var cpu, mem int64
+  for _, c := range td.ContainerDefinitions {
+    cpu += aws.Int64Value(c.Cpu)
+    // The soft limit is what gets reserved on the instance, the hard limit only if there isn't one.
+    if m := aws.Int64Value(c.MemoryReservation); m > 0 {
+      mem += m
+    } else {
+      mem += aws.Int64Value(c.Memory)
+    }
+    // With awsvpc each task gets its own ENI, so host ports don't collide.
+    if aws.StringValue(td.NetworkMode) == ecs.NetworkModeAwsvpc { continue }
+    for _, pm := range c.PortMappings {
+      port := aws.Int64Value(pm.HostPort)
+      if aws.StringValue(td.NetworkMode) == ecs.NetworkModeHost { port = aws.Int64Value(pm.ContainerPort) }
+      if port == 0 { continue } // Dynamic port.
+      protocol := aws.StringValue(pm.Protocol)
+      if protocol == "" { protocol = ecs.TransportProtocolTcp }
+      needs.Ports = append(needs.Ports, fmt.Sprintf("%s/%d", protocol, port))
+    }
+  }
+
+  // Task level sizes, when given, are what the scheduler reserves.
+  needs.Cpu, needs.Mem = cpu, mem
+  if n, err := strconv.ParseInt(aws.StringValue(td.Cpu), 10, 64); err == nil { needs.Cpu = n }
+  if n, err := strconv.ParseInt(aws.StringValue(td.Memory), 10, 64); err == nil { needs.Mem = n }
+  return needs
+}
+
+func newFitInstance(ci *ecs.ContainerInstance) (*fitInstance) {
+  fi := &fitInstance{
+    Arn: aws.StringValue(ci.ContainerInstanceArn),
+    Ec2Id: aws.StringValue(ci.Ec2InstanceId),
+    Cpu: getCpu(ci.RemainingResources),
+    Mem: getMemory(ci.RemainingResources),
+    Ports: make(map[string]bool),
+  }
+  fi.StartCpu, fi.StartMem = fi.Cpu, fi.Mem
+  for _, r := range ci.RemainingResources {
+    protocol := ""
+    switch aws.StringValue(r.Name) {
+    case "PORTS": protocol = ecs.TransportProtocolTcp
+    case "PORTS_UDP": protocol = ecs.TransportProtocolUdp
+    default: continue
+    }
+    for _, p := range r.StringSetValue {
+      fi.Ports[fmt.Sprintf("%s/%s", protocol, *p)] = true
+    }
+  }
+  if s := aws.StringValue(ci.Status); s != ecs.ContainerInstanceStatusActive {
+    fi.Unavailable = fmt.Sprintf("status %s", s)
+  } else if !aws.BoolValue(ci.AgentConnected) {
+    fi.Unavailable = "agent disconnected"
+  }
+  return fi
+}
+
+// Empty if another copy fits, otherwise what's in the way.
+func (fi *fitInstance) blocker(needs fitNeeds) (string) {
+  if fi.Unavailable != "" { return fi.Unavailable }
+  blocks := make([]string, 0)
+  if needs.Cpu > fi.Cpu { blocks = append(blocks, fmt.Sprintf("CPU (%d left, %d needed)", fi.Cpu, needs.Cpu)) }
+  if needs.Mem > fi.Mem { blocks = append(blocks, fmt.Sprintf("memory (%d left, %d needed)", fi.Mem, needs.Mem)) }
+  conflicts := make([]string, 0)
+  for _, p := range needs.Ports {
+    if fi.Ports[p] { conflicts = append(conflicts, p) }
+  }
+  if len(conflicts) > 0 { blocks = append(blocks, fmt.Sprintf("port conflict %s", strings.Join(conflicts, ","))) }
+  return strings.Join(blocks, ", ")
+}
+
+func (fi *fitInstance) place(needs fitNeeds) {
+  fi.Cpu -= needs.Cpu
+  fi.Mem -= needs.Mem
+  for _, p := range needs.Ports { fi.Ports[p] = true }
+  fi.Placed++
+}
+
+// Places up to count copies, or as many as will fit if count is 0, binpacking on memory
+// the way the ECS binpack placement strategy does: each copy goes to the instance
+// with the least memory left that can still take it. Returns the number placed.
+func fitTasks(instances []*fitInstance, needs fitNeeds, count int) (int, error) {
+  if count <= 0 && needs.Cpu <= 0 && needs.Mem <= 0 && len(needs.Ports) == 0 {
+    return 0, fmt.Errorf("The task needs no CPU, memory or ports, give a --count to fit")
+  }
+  placed := 0
+  for count <= 0 || placed < count {
+    var best *fitInstance
+    for _, fi := range instances {
+      if fi.blocker(needs) != "" { continue }
+      if best == nil || fi.Mem < best.Mem || (fi.Mem == best.Mem && fi.Arn < best.Arn) { best = fi }
+    }
+    if best == nil { break }
+    best.place(needs)
+    placed++
+  }
+  return placed, nil
+}
+
+func doFitTaskDefinition(tdArn, clusterName string, count int, sess *session.Session) (error) {
+  td, err := awslib.GetTaskDefinition(tdArn, sess)
+  if err != nil { return err }
+  needs := taskFitNeeds(td)
+
+  ciMap, _, err := awslib.GetContainerMaps(clusterName, sess)
+  if err != nil { return err }
+  instances := make([]*fitInstance, 0, len(ciMap))
+  for _, ci := range ciMap {
+    if ci.Instance != nil { instances = append(instances, newFitInstance(ci.Instance)) }
+  }
+  sort.Slice(instances, func(i, j int) bool { return instances[i].Arn < instances[j].Arn })
+
+  ports := "none"
+  if len(needs.Ports) > 0 { ports = strings.Join(needs.Ports, ", ") }
+  fmt.Printf("%s%s needs CPU %d, MEM %d, host ports %s.%s\n", titleColor,
+    awslib.ShortArnString(td.TaskDefinitionArn), needs.Cpu, needs.Mem, ports, resetColor)
+  if len(instances) == 0 {
+    fmt.Printf("%sThis cluster has no instances attached to it.%s\n", warnColor, resetColor)
+    return nil
+  }
+
+  placed, err := fitTasks(instances, needs, count)
+  if err != nil { return err }
+  switch {
+  case count <= 0:
+    fmt.Printf("%s%d copies fit on cluster %s.%s\n", infoColor, placed, clusterName, resetColor)
+  case placed < count:
+    fmt.Printf("%sOnly %d of %d copies fit on cluster %s.%s\n", failColor, placed, count, clusterName, resetColor)
+  default:
+    fmt.Printf("%sAll %d copies fit on cluster %s.%s\n", successColor, placed, clusterName, resetColor)
+  }
+
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sEC2\tCPU-R\tMEM-R\tCopies\tCPU After\tMEM After\tBlocked By\tARN%s\n", titleColor, resetColor)
+  for _, fi := range instances {
+    color := nullColor
+    if fi.Placed == 0 { color = warnColor }
+    blocked := "-"
+    if count <= 0 || placed < count { blocked = fi.blocker(needs) }
+    fmt.Fprintf(w, "%s%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s%s\n", color, fi.Ec2Id, fi.StartCpu, fi.StartMem,
+      fi.Placed, fi.Cpu, fi.Mem, blocked, awslib.ShortArnString(&fi.Arn), resetColor)
+  }
+  w.Flush()
+  return nil
+}
@@ -0,0 +1,98 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func testFitInstance(arn string, cpu, mem int64, ports ...string) (*fitInstance) {
+  return newFitInstance(&ecs.ContainerInstance{
+    ContainerInstanceArn: aws.String(arn),
+    Ec2InstanceId: aws.String("i-" + arn),
+    Status: aws.String(ecs.ContainerInstanceStatusActive),
+    AgentConnected: aws.Bool(true),
+    RemainingResources: []*ecs.Resource{
+      {Name: aws.String("CPU"), IntegerValue: aws.Int64(cpu)},
+      {Name: aws.String("MEMORY"), IntegerValue: aws.Int64(mem)},
+      {Name: aws.String("PORTS"), StringSetValue: aws.StringSlice(ports)},
+    },
+  })
+}
+
+func TestTaskFitNeeds(t *testing.T) {
+  td := &ecs.TaskDefinition{
+    NetworkMode: aws.String(ecs.NetworkModeBridge),
+    ContainerDefinitions: []*ecs.ContainerDefinition{
+      {Cpu: aws.Int64(256), Memory: aws.Int64(512), PortMappings: []*ecs.PortMapping{
+        {ContainerPort: aws.Int64(80), HostPort: aws.Int64(8080)},
+        {ContainerPort: aws.Int64(81), HostPort: aws.Int64(0)},
+      }},
+      {Cpu: aws.Int64(128), MemoryReservation: aws.Int64(256), PortMappings: []*ecs.PortMapping{
+        {ContainerPort: aws.Int64(53), HostPort: aws.Int64(53), Protocol: aws.String(ecs.TransportProtocolUdp)},
+      }},
+    },
+  }
+  needs := taskFitNeeds(td)
+  assert.Equal(t, int64(384), needs.Cpu)
+  assert.Equal(t, int64(768), needs.Mem)
+  assert.Equal(t, []string{"tcp/8080", "udp/53"}, needs.Ports)
+
+  td.Cpu, td.Memory = aws.String("1024"), aws.String("2048")
+  td.NetworkMode = aws.String(ecs.NetworkModeAwsvpc)
+  needs = taskFitNeeds(td)
+  assert.Equal(t, int64(1024), needs.Cpu)
+  assert.Equal(t, int64(2048), needs.Mem)
+  assert.Empty(t, needs.Ports)
+}
+
+func TestTaskFitNeedsPrefersMemoryReservation(t *testing.T) {
+  td := &ecs.TaskDefinition{
+    ContainerDefinitions: []*ecs.ContainerDefinition{
+      {Cpu: aws.Int64(256), Memory: aws.Int64(1024), MemoryReservation: aws.Int64(512)},
+      {Cpu: aws.Int64(128), Memory: aws.Int64(256)},
+    },
+  }
+  needs := taskFitNeeds(td)
+  assert.Equal(t, int64(768), needs.Mem)
+
+  // 512 + 256 fits twice in 1536, the hard limits would only fit once.
+  placed, err := fitTasks([]*fitInstance{testFitInstance("a", 4096, 1536)}, needs, 0)
+  if assert.NoError(t, err) {
+    assert.Equal(t, 2, placed)
+  }
+}
+
+func TestFitTasksBinpacksOnMemory(t *testing.T) {
+  big := testFitInstance("big", 2048, 4096)
+  small := testFitInstance("small", 2048, 1024)
+  placed, err := fitTasks([]*fitInstance{big, small}, fitNeeds{Cpu: 128, Mem: 512}, 3)
+  if assert.NoError(t, err) {
+    assert.Equal(t, 3, placed)
+    assert.Equal(t, 2, small.Placed, "Should fill the instance with the least memory first.")
+    assert.Equal(t, 1, big.Placed)
+  }
+}
+
+func TestFitTasksBlockers(t *testing.T) {
+  cpuBound := testFitInstance("cpu", 100, 4096)
+  memBound := testFitInstance("mem", 4096, 100)
+  portBound := testFitInstance("port", 4096, 4096, "22", "80")
+  needs := fitNeeds{Cpu: 256, Mem: 256, Ports: []string{"tcp/80"}}
+  placed, err := fitTasks([]*fitInstance{cpuBound, memBound, portBound}, needs, 0)
+  if assert.NoError(t, err) {
+    assert.Equal(t, 0, placed)
+    assert.Contains(t, cpuBound.blocker(needs), "CPU")
+    assert.Contains(t, memBound.blocker(needs), "memory")
+    assert.Contains(t, portBound.blocker(needs), "port conflict tcp/80")
+  }
+
+  // Each copy takes the port, so only one fits per instance.
+  a := testFitInstance("a", 4096, 4096)
+  b := testFitInstance("b", 4096, 4096)
+  placed, err = fitTasks([]*fitInstance{a, b}, needs, 0)
+  if assert.NoError(t, err) {
+    assert.Equal(t, 2, placed)
+  }
+}
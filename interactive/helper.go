@@ -7,6 +7,7 @@ import(
   "text/tabwriter"
   "time"
   "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/chzyer/readline"
 )
 func collectStringPointers(strs []*string) (string) {
   cs := "["
@@ -50,4 +51,19 @@ func printFailures(failures []*ecs.Failure) {
 
 func nowString() (string) {
   return fmt.Sprintf("[%s] ", time.Now().Local().Format(time.RFC1123))
-}
\ No newline at end of file
+}
+
+// Ask a yes/no question on the terminal, anything but yes is a no.
+func confirm(question string) (bool) {
+  line, err := readline.Line(fmt.Sprintf("%s%s (yes/no):%s ", warnColor, question, resetColor))
+  if err != nil { return false }
+  answer := strings.ToLower(strings.TrimSpace(line))
+  return answer == "yes" || answer == "y"
+}
+
+// Ask for something, eg. the name of what's about to be deleted, to be typed back.
+func confirmTyped(question, expected string) (bool) {
+  line, err := readline.Line(fmt.Sprintf("%s%s? Type %s to confirm:%s ", warnColor, question, expected, resetColor))
+  if err != nil { return false }
+  return strings.TrimSpace(line) == expected
+}
@@ -0,0 +1,194 @@
+package interactive
+
+import (
+  "bufio"
+  "encoding/json"
+  "fmt"
+  "os"
+  "regexp"
+  "strconv"
+  "strings"
+  "text/tabwriter"
+  "time"
+  "github.com/chzyer/readline"
+)
+
+const (
+  historyFileName = "history"
+  historyRecalled = 500 // Entries loaded for readline's up arrow.
+  maskedValue = "****"
+)
+
+// A line from the prompt as kept in ~/.ecs-pilot/history, one JSON object a line.
+type historyEntry struct {
+  Time time.Time `json:"time"`
+  Cluster string `json:"cluster"`
+  Profile string `json:"profile"`
+  Command string `json:"command"`
+  Masked bool `json:"masked,omitempty"` // Secrets were replaced with ****, so it can't be re-run.
+}
+
+var (
+  currentProfile string
+  commandCluster string // The cluster the last command ran on, which a cluster-name argument can change.
+)
+
+func historyFile() (string, error) {
+  return configFilePath(historyFileName)
+}
+
+// Oldest first, an entry's number is its place in the file from 1.
+func readHistory() ([]historyEntry, error) {
+  fn, err := historyFile()
+  if err != nil { return nil, err }
+  f, err := os.Open(fn)
+  if os.IsNotExist(err) { return []historyEntry{}, nil }
+  if err != nil { return nil, err }
+  defer f.Close()
+
+  entries := make([]historyEntry, 0)
+  scanner := bufio.NewScanner(f)
+  scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+  for scanner.Scan() {
+    var e historyEntry
+    // Keep the numbering even if a line is damaged.
+    if err := json.Unmarshal(scanner.Bytes(), &e); err != nil { e.Command = "" }
+    entries = append(entries, e)
+  }
+  return entries, scanner.Err()
+}
+
+func appendHistory(e historyEntry) (error) {
+  fn, err := historyFile()
+  if err != nil { return err }
+  b, err := json.Marshal(e)
+  if err != nil { return err }
+  f, err := os.OpenFile(fn, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+  if err != nil { return err }
+  _, err = f.Write(append(b, '\n'))
+  if cerr := f.Close(); err == nil { err = cerr }
+  return err
+}
+
+// Saves the line with the context it ran in.
+func recordHistory(line, clusterName string) {
+  command, masked := maskSecrets(line)
+  err := appendHistory(historyEntry{Time: time.Now(), Cluster: clusterName, Profile: currentProfile,
+    Command: command, Masked: masked})
+  if err != nil { fmt.Printf("%sCan't save history: %s%s\n", warnColor, err, resetColor) }
+}
+
+// Task environment values, the KEY=VALUE words after task run's task definition, are
+// replaced by ****, except for KEY=@file where the file name is all there is to see.
+func maskSecrets(line string) (string, bool) {
+  words, err := tokenize(line)
+  if err != nil { return maskUnparsed(line) }
+  if expanded, err := expandAlias(words, getAliases()); err == nil { words = expanded }
+  if len(words) < 3 || words[0] != "task" || words[1] != "run" { return line, false }
+
+  masked := false
+  positional := 0
+  for i := 2; i < len(words); i++ {
+    w := words[i]
+    if strings.HasPrefix(w, "-") { continue }
+    positional++
+    kv := strings.SplitN(w, "=", 2)
+    // The task definition and cluster come before the environment.
+    if positional > 1 && len(kv) == 2 && !(strings.HasPrefix(kv[1], "@") && !strings.HasPrefix(kv[1], "@@")) {
+      words[i] = kv[0] + "=" + maskedValue
+      masked = true
+    }
+  }
+  if !masked { return line, false }
+  quoted := make([]string, 0, len(words))
+  for _, w := range words { quoted = append(quoted, quoteWord(w)) }
+  return strings.Join(quoted, " "), true
+}
+
+// A line that doesn't tokenize, eg. with a missing closing quote, is cut at the first
+// KEY=VALUE word, since an open quote could have taken in any of the rest.
+func maskUnparsed(line string) (string, bool) {
+  fields := strings.Fields(line)
+  for i, w := range fields {
+    if strings.HasPrefix(w, "-") || !strings.Contains(w, "=") { continue }
+    fields[i] = strings.SplitN(w, "=", 2)[0] + "=" + maskedValue
+    return strings.Join(fields[:i+1], " "), true
+  }
+  return line, false
+}
+
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./#*-]+$`)
+
+// Quotes w so tokenize gives it back.
+func quoteWord(w string) (string) {
+  if plainWord.MatchString(w) { return w }
+  return "'" + strings.Replace(w, "'", `'\''`, -1) + "'"
+}
+
+// The last of the saved history for readline's up arrow.
+func recallHistory() {
+  entries, err := readHistory()
+  if err != nil {
+    fmt.Printf("%sCan't read history: %s%s\n", warnColor, err, resetColor)
+    return
+  }
+  if len(entries) > historyRecalled { entries = entries[len(entries)-historyRecalled:] }
+  for _, e := range entries {
+    if e.Command != "" { readline.AddHistory(e.Command) }
+  }
+}
+
+// !n is history entry n, !! the last one. Anything else is left alone.
+func expandHistory(line string) (string, bool, error) {
+  trimmed := strings.TrimSpace(line)
+  if !strings.HasPrefix(trimmed, "!") || len(trimmed) < 2 { return line, false, nil }
+  entries, err := readHistory()
+  if err != nil { return "", true, err }
+
+  n := len(entries)
+  if trimmed != "!!" {
+    n, err = strconv.Atoi(trimmed[1:])
+    if err != nil { return "", true, fmt.Errorf("Use !n to re-run history entry n, or !! for the last one") }
+  }
+  if n < 1 || n > len(entries) || entries[n-1].Command == "" { return "", true, fmt.Errorf("There's no history entry %d", n) }
+  e := entries[n-1]
+  if e.Masked { return "", true, fmt.Errorf("History entry %d had secrets masked, type it again: %s", n, e.Command) }
+  return e.Command, true, nil
+}
+
+// The last count entries, for this cluster only and matching grep if asked.
+func doHistory(thisCluster bool, grep string, count int) (error) {
+  var re *regexp.Regexp
+  if grep != "" {
+    var err error
+    if re, err = regexp.Compile("(?i)" + grep); err != nil { return fmt.Errorf("Bad --grep pattern: %s", err) }
+  }
+  entries, err := readHistory()
+  if err != nil { return err }
+
+  type numbered struct {
+    n int
+    e historyEntry
+  }
+  shown := make([]numbered, 0)
+  for i, e := range entries {
+    if e.Command == "" { continue }
+    if thisCluster && e.Cluster != currentCluster { continue }
+    if re != nil && !re.MatchString(e.Command) { continue }
+    shown = append(shown, numbered{i+1, e})
+  }
+  if len(shown) == 0 {
+    fmt.Printf("No matching history.\n")
+    return nil
+  }
+  if count > 0 && len(shown) > count { shown = shown[len(shown)-count:] }
+
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%s#\tTime\tProfile\tCluster\tCommand%s\n", titleColor, resetColor)
+  for _, s := range shown {
+    fmt.Fprintf(w, "%s%d\t%s\t%s\t%s\t%s%s\n", nullColor, s.n, s.e.Time.Local().Format(humanTimeFormat),
+      s.e.Profile, s.e.Cluster, s.e.Command, resetColor)
+  }
+  w.Flush()
+  return nil
+}
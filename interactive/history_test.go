@@ -0,0 +1,42 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestMaskSecrets(t *testing.T) {
+  aliases = map[string]string{"tr": "task run"}
+  tests := []struct {
+    line string
+    masked string
+  }{
+    {"task run web prod DB_PASSWORD=hunter2", "task run web prod DB_PASSWORD=****"},
+    {`task run web TOKEN="a b" CERT=@cert.pem`, "task run web TOKEN=**** CERT=@cert.pem"},
+    {"task run --launch-type=FARGATE web KEY=v", "task run --launch-type=FARGATE web KEY=****"},
+    {"tr web KEY=v", "task run web KEY=****"},
+    {"task run web prod", ""},
+    {`task run web PASSWORD="hunter2`, "task run web PASSWORD=****"},
+    {`task run web A=1 TOKEN="x y`, "task run web A=****"},
+    {"instance create --tag Name=web", ""},
+  }
+  for _, test := range tests {
+    masked, ok := maskSecrets(test.line)
+    if test.masked == "" {
+      assert.False(t, ok, test.line)
+      assert.Equal(t, test.line, masked)
+    } else {
+      assert.True(t, ok, test.line)
+      assert.Equal(t, test.masked, masked)
+    }
+  }
+}
+
+func TestQuoteWord(t *testing.T) {
+  words := []string{"task", "KEY=a b", "it's", `{"a": 1}`, "#3", ""}
+  quoted := ""
+  for _, w := range words { quoted += quoteWord(w) + " " }
+  back, err := tokenize(quoted)
+  assert.NoError(t, err)
+  assert.Equal(t, words, back)
+}
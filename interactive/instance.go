@@ -3,12 +3,14 @@ package interactive
 import (
   "fmt"
   "os"
+  "sort"
   "time"
   "text/tabwriter"
   "github.com/aws/aws-sdk-go/aws"
   "github.com/aws/aws-sdk-go/aws/session"
   "github.com/aws/aws-sdk-go/service/ecs"
   "github.com/aws/aws-sdk-go/service/ec2"
+  "ecs-pilot/metrics"
   // "github.com/Sirupsen/logrus"
 
 
@@ -17,19 +19,33 @@ import (
   "github.com/jdrivas/awslib"
 )
 
-func doListContainerInstances(sess *session.Session) (error) {
+func doListContainerInstances(window time.Duration, sess *session.Session) (error) {
   ciMap, ecMap, err := awslib.GetContainerMaps(currentCluster, sess)
   if err != nil {return fmt.Errorf("Can't get container instances for %s: %s", currentCluster, err)}
 
+  ec2Ids := make([]string, 0, len(ciMap))
+  for _, ci := range ciMap {
+    if ci.Instance != nil { ec2Ids = append(ec2Ids, *ci.Instance.Ec2InstanceId) }
+  }
+  cpu, err := metrics.InstanceCPU(ec2Ids, window, sess)
+  if err != nil {
+    fmt.Printf("%s%s%s\n", warnColor, err, resetColor)
+    cpu = make(map[string]*metrics.Series)
+  }
+
   instanceNoun := "instances"
   if len(ciMap) == 1 { instanceNoun = "instance"}
   fmt.Printf("%s%s %s: %d %s.%s\n", 
     emphColor, time.Now().Local().Format(humanTimeFormat), currentCluster, len(ciMap), instanceNoun, resetColor)
   w := tabwriter.NewWriter(os.Stdout, 4, 2, 2, ' ', 0)
-  fmt.Fprintf(w, "%sPublic Address\tInteral Address\tType\tActive\tUptime\tA-CPU\tR-CPU\tA-Mem\tR-Mem\tEC2ID\tARN%s\n", 
-    titleColor, resetColor)
-  for ciArn, awslibCi := range ciMap {
-    ci := awslibCi.Instance
+  fmt.Fprintf(w, "%s#\tPublic Address\tInteral Address\tType\tActive\tUptime\tA-CPU\tR-CPU\tA-Mem\tR-Mem\tCPU%%\tCPU %s\tEC2ID\tARN%s\n", 
+    titleColor, shortDurationString(window), resetColor)
+  ciArns := make([]string, 0, len(ciMap))
+  for ciArn := range ciMap { ciArns = append(ciArns, ciArn) }
+  sort.Strings(ciArns)
+  recordResults(instanceRefs, currentCluster, ciArns)
+  for i, ciArn := range ciArns {
+    ci := ciMap[ciArn].Instance
     ecI := ecMap[*ci.Ec2InstanceId]
     if ecI == nil {return fmt.Errorf("Got a nil address for the EC2 Instance.\n")}
     addr := "unassigned"
@@ -51,9 +67,10 @@ func doListContainerInstances(sess *session.Session) (error) {
       if rMem < 512 {eColor = warnColor}
     }
     if ecI.InstanceType != nil {iType = *ecI.InstanceType}
-    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s%s\n", eColor,
-      addr, iaddr, iType, *ci.Status, uptime, aCpu, rCpu, aMem, rMem, 
-      *ci.Ec2InstanceId, awslib.ShortArnString(&ciArn),resetColor) 
+    used := cpu[*ci.Ec2InstanceId]
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s%s\n", eColor, refNumberString(i),
+      addr, iaddr, iType, *ci.Status, uptime, aCpu, rCpu, aMem, rMem, metrics.PercentString(used),
+      used.Sparkline(100), *ci.Ec2InstanceId, awslib.ShortArnString(&ciArn),resetColor) 
   }
   w.Flush()
 
@@ -245,16 +262,29 @@ func attributeString(attr *ecs.Attribute) (string) {
   return fmt.Sprintf("%s: %s", *attr.Name, value)
 }
 
-func doCreateContainerInstance(sess *session.Session) (error) {
+// With no template or flags we launch with the awslib defaults, otherwise the
+// launch is built from the named config file template overridden by the flags.
+func doCreateContainerInstance(templateName string, flags launchTemplate, count int64, sess *session.Session) (error) {
   thisClusterName := currentCluster // TODO: Check if this is a copying the string over for the OnWait routines below.
-  nameTag := fmt.Sprintf("%s - ecs instance", currentCluster)
-  tags := []*ec2.Tag{
-    {
-      Key: aws.String("Name"),
-      Value: aws.String(nameTag),
-    },
+  if count < 1 { return fmt.Errorf("Count must be at least 1") }
+
+  var resp *ec2.Reservation
+  var err error
+  if templateName == "" && flags.isEmpty() {
+    resp, err = launchDefaultInstances(thisClusterName, count, sess)
+  } else {
+    template := launchTemplate{}
+    if templateName != "" {
+      config, err := getConfig()
+      if err != nil { return err }
+      t, ok := config.LaunchTemplates[templateName]
+      if !ok || t == nil { return fmt.Errorf("No launch template %s in the config file", templateName) }
+      template = *t
+    }
+    spec, serr := template.merge(&flags).launchSpec(thisClusterName, sess)
+    if serr != nil { return serr }
+    resp, err = launchInstances(spec, count, sess)
   }
-  resp, err := awslib.LaunchInstanceWithTags(thisClusterName, tags, sess)
   if err != nil {
     return err
   }
@@ -268,7 +298,9 @@ func doCreateContainerInstance(sess *session.Session) (error) {
       fmt.Printf("%s\n", shortInstanceString(inst))
     }
   } else {
-    fmt.Printf("%sLaunched (%d) EC2Instances:%s\n", warnColor, len(resp.Instances), resetColor)
+    color := successColor
+    if int64(len(resp.Instances)) != count { color = warnColor }
+    fmt.Printf("%sOn cluster %s launched (%d) EC2Instances:%s\n", color, thisClusterName, len(resp.Instances), resetColor)
     if verbose {
       fmt.Printf("%#v\n",resp) 
     } else {
@@ -284,7 +316,10 @@ func doCreateContainerInstance(sess *session.Session) (error) {
     iIds = append(iIds, inst.InstanceId)
   }
   startTime := time.Now()
+  running := jobs.start(thisClusterName, fmt.Sprintf("%d EC2 instances running", len(iIds)))
   awslib.OnInstanceRunning(resp, sess, func(err error) { 
+    defer running.finish(err)
+    if running.cancelled() { return }
     if err == nil {
       instances, err := awslib.GetInstancesForIds(iIds, sess)
       if err == nil {
@@ -309,12 +344,13 @@ func doCreateContainerInstance(sess *session.Session) (error) {
     } 
   })
 
-  // TODO: For now we'll just look for 1 instnace. This is the use case here anyway.
-  // Maybe launch this from within the OnInstanceRunning callback?
-  if iIds[0] != nil {
-    waitForId := *iIds[0]
-    fmt.Printf("Will notify when ContainerInstance for EC2 Instance %s is Active.\n", waitForId)
+  fmt.Printf("Will notify when the ContainerInstances for the (%d) EC2 Instances are Active.\n", len(iIds))
+  for _, id := range iIds {
+    waitForId := *id
+    active := jobs.start(thisClusterName, fmt.Sprintf("container instance on %s active", waitForId))
     awslib.OnContainerInstanceActive(thisClusterName, waitForId, sess, func(cis *ecs.ContainerInstance, err error) {
+      defer active.finish(err)
+      if active.cancelled() { return }
       if err == nil {
         inst, err := awslib.GetInstanceForId(waitForId, sess)
         if err == nil {
@@ -329,7 +365,7 @@ func doCreateContainerInstance(sess *session.Session) (error) {
         log.Error(nil, "Failed on waiting for instance active.", err)
       }
     })
-  } 
+  }
 
   return nil
 }
@@ -347,11 +383,56 @@ func shortInstanceString(inst *ec2.Instance) (s string) {
   return s
 }
 
-func doTerminateContainerInstance(sess *session.Session) (error) {
+func doTerminateContainerInstance(force bool, sess *session.Session) (error) {
 
   ciArn, err := awslib.LongArnString(interContainerArn, awslib.ContainerInstanceType, sess)
   if err != nil { return err }
-  resp, err := awslib.TerminateContainerInstance(currentCluster, ciArn, sess)
+  clusterName := currentCluster
+  question := fmt.Sprintf("Terminate container instance %s on %s", awslib.ShortArnString(&ciArn), clusterName)
+  if ok, err := confirmDestructive(question, clusterName, "", force); !ok { return err }
+  if !drainArg {
+    return terminateContainerInstance(clusterName, ciArn, sess)
+  }
+
+  return drainContainerInstance(clusterName, ciArn, sess, func(err error) {
+    if err == nil {
+      err = terminateContainerInstance(clusterName, ciArn, sess)
+    }
+    if err != nil {
+      fmt.Printf("\n%sNot terminating container instance %s: %s%s\n", failColor, awslib.ShortArnString(&ciArn), err, resetColor)
+    }
+  })
+}
+
+// awslib launches one instance at a time, so count of them are launched one after another
+// to have them all the same. Those launched before a failure are still returned.
+func launchDefaultInstances(clusterName string, count int64, sess *session.Session) (*ec2.Reservation, error) {
+  nameTag := fmt.Sprintf("%s - ecs instance", clusterName)
+  tags := []*ec2.Tag{
+    {
+      Key: aws.String("Name"),
+      Value: aws.String(nameTag),
+    },
+  }
+  var launched *ec2.Reservation
+  for i := int64(0); i < count; i++ {
+    resp, err := awslib.LaunchInstanceWithTags(clusterName, tags, sess)
+    if err != nil {
+      if launched == nil { return nil, err }
+      fmt.Printf("%sOnly launched %d of %d instances: %s%s\n", warnColor, len(launched.Instances), count, err, resetColor)
+      break
+    }
+    if launched == nil {
+      launched = resp
+    } else {
+      launched.Instances = append(launched.Instances, resp.Instances...)
+    }
+  }
+  return launched, nil
+}
+
+func terminateContainerInstance(clusterName, ciArn string, sess *session.Session) (error) {
+  resp, err := awslib.TerminateContainerInstance(clusterName, ciArn, sess)
   if err != nil { return err }
 
   termInstances := resp.TerminatingInstances
@@ -359,7 +440,7 @@ func doTerminateContainerInstance(sess *session.Session) (error) {
     fmt.Printf("%sGot (%d) instances terminating, expecting only 1.%s\nn", 
       warnColor, len(resp.TerminatingInstances), resetColor)
   }
-  fmt.Printf("%sTerminated container instance \n\t%s%s\n", warnColor, interContainerArn, resetColor)
+  fmt.Printf("%sTerminated container instance \n\t%s%s\n", warnColor, awslib.ShortArnString(&ciArn), resetColor)
   fmt.Printf("Terminating (%d) EC2 Instances:\n", len(termInstances))
   for i, ti := range termInstances {
     fmt.Printf("%d. %s going from %s (%d) => %s (%d)\n", i+1, 
@@ -368,7 +449,10 @@ func doTerminateContainerInstance(sess *session.Session) (error) {
   }
 
   instanceToWatch := resp.TerminatingInstances[0].InstanceId
+  job := jobs.start(clusterName, fmt.Sprintf("EC2 instance %s terminated", *instanceToWatch))
   awslib.OnInstanceTerminated(instanceToWatch, sess, func(err error) {
+    defer job.finish(err)
+    if job.cancelled() { return }
     if err == nil {
       fmt.Printf("%sEC2 Instance Termianted: %s.%s\n", warnColor, *instanceToWatch, resetColor)
     } else {
@@ -1,9 +1,11 @@
 package interactive
 import (
+  "errors"
   "strings"
   "fmt"
   "io"
   "time"
+  "ecs-pilot/doctor"
   "ecs-pilot/server"
   // "ecs-pilot/server"
   "github.com/alecthomas/kingpin"
@@ -47,6 +49,9 @@ var (
 )
 
 const defaultCluster = "minecraft"
+
+// What doICommand returns for a line that doesn't parse, once it's said so.
+var errBadCommand = errors.New("bad command")
 var (
   currentCluster = defaultCluster
   currentSession *session.Session
@@ -64,6 +69,30 @@ var (
   debugCmd *kingpin.CmdClause
   debug bool
   interTestString []string
+  waitCmd *kingpin.CmdClause
+  jobsCmd *kingpin.CmdClause
+  listJobsCmd *kingpin.CmdClause
+  cancelJobCmd *kingpin.CmdClause
+  waitJobCmd *kingpin.CmdClause
+  jobIdArg int
+  sourceCmd *kingpin.CmdClause
+  sourceFileArg string
+  sourceVarsArg map[string]string
+  aliasCmd *kingpin.CmdClause
+  aliasArg string
+  historyCmd *kingpin.CmdClause
+  historyClusterArg bool
+  historyGrepArg string
+  historyCountArg int
+  auditCmd *kingpin.CmdClause
+  auditShowCmd *kingpin.CmdClause
+  auditClusterArg string
+  auditUserArg string
+  auditGrepArg string
+  auditCountArg int
+  doctorCmd *kingpin.CmdClause
+  doctorPermissionsCmd *kingpin.CmdClause
+  permissionLevelArg string
 
   // Command flags
   sortByCreatedAt bool
@@ -71,12 +100,21 @@ var (
 
   // Clusters
   useClusterCmd *kingpin.CmdClause
+  clusterUseCmd *kingpin.CmdClause
 
   interCluster *kingpin.CmdClause
   createCluster *kingpin.CmdClause
   deleteCluster *kingpin.CmdClause
   interListClusters *kingpin.CmdClause
   interDescribeCluster *kingpin.CmdClause
+  rollInstancesCmd *kingpin.CmdClause
+  fitClusterCmd *kingpin.CmdClause
+  fitCountArg int
+  clusterCostCmd *kingpin.CmdClause
+  costJsonArg bool
+  rollBatchArg int
+  rollAmiArg string
+  rollRestartArg bool
 
   // Containers
   instance *kingpin.CmdClause
@@ -84,20 +122,56 @@ var (
   interDescribeContainerInstance *kingpin.CmdClause
   interDescribeAllContainerInstances *kingpin.CmdClause
   interCreateContainerInstance *kingpin.CmdClause
+  launchTemplateArg string
+  launchArgs launchTemplate
+  launchCountArg int64
   interTerminateContainerInstance *kingpin.CmdClause
+  drainContainerInstanceCmd *kingpin.CmdClause
+  drainArg bool
+  forceArg bool
 
   clusterNameArg string
   interContainerArn string
 
+  // Capacity
+  capacityCmd *kingpin.CmdClause
+  listCapacityCmd *kingpin.CmdClause
+  describeCapacityCmd *kingpin.CmdClause
+  setCapacityCmd *kingpin.CmdClause
+  capacityProviderArg string
+  capacityArgs capacitySettings
+
   // Services
   serviceCmd *kingpin.CmdClause
   listServicesCmd *kingpin.CmdClause
   describeServiceCmd *kingpin.CmdClause
+  serviceEventsCmd *kingpin.CmdClause
+  clusterEventsCmd *kingpin.CmdClause
+  eventsSinceArg time.Duration
+  eventsGrepArg string
+  eventsFollowArg bool
+  metricsWindowArg time.Duration
   createServiceCmd *kingpin.CmdClause
   restartServiceCmd *kingpin.CmdClause
   updateServiceDesiredCountCmd *kingpin.CmdClause
   deleteServiceCmd *kingpin.CmdClause
+  exportServiceCmd *kingpin.CmdClause
+  autoScaleCmd *kingpin.CmdClause
+  showAutoScaleCmd *kingpin.CmdClause
+  setAutoScaleCmd *kingpin.CmdClause
+  removeAutoScaleCmd *kingpin.CmdClause
+  deployServiceCmd *kingpin.CmdClause
+  startDeployCmd *kingpin.CmdClause
+  rollbackDeployCmd *kingpin.CmdClause
+  deployStrategyArg string
+  deployAppArg string
+  deployGroupArg string
   serviceNameArg string
+  minCountArg int64
+  maxCountArg int64
+  cpuTargetArg float64
+  memoryTargetArg float64
+  exportDirArg string
   instanceCountArg int64
 
   // Tasks
@@ -109,8 +183,13 @@ var (
   interRunTask *kingpin.CmdClause
   taskDefinitionArnArg string
   interStopTask *kingpin.CmdClause
+  whyTaskCmd *kingpin.CmdClause
+  stoppedArg bool
+  sinceArg time.Duration
+  logLinesArg int64
   interTaskArn string
   taskEnv map[string]string
+  taskNetworkArgs taskNetworkOptions
 
   // Task Defintions
   interTaskDefinition *kingpin.CmdClause
@@ -132,6 +211,11 @@ var (
   serverCmd *kingpin.CmdClause
   serverAddressArg string
 
+  // Apply
+  applyCmd *kingpin.CmdClause
+  specDirArg string
+  pruneArg bool
+
   log = sl.New()
 
 )
@@ -139,14 +223,44 @@ var (
 func init() {
 
   taskEnv = make(map[string]string)
+  sourceVarsArg = make(map[string]string)
+  launchArgs = launchTemplate{Tags: make(map[string]string), AgentConfig: make(map[string]string)}
 
   interApp = kingpin.New("", "Interactive mode.").Terminate(doTerminate)
+  interApp.Flag("dry-run", "Print the first AWS call that would change something instead of making it, the command stops there.").BoolVar(&dryRunArg)
+  interApp.Flag("yes", "Don't ask before destructive commands (protected clusters still need --force).").Short('y').BoolVar(&yesArg)
 
   // state
   debugCmd = interApp.Command("debug", "toggle debug logging and description.")
   interVerbose = interApp.Command("verbose", "toggle verbose mode.")
   interExit = interApp.Command("exit", "exit the program. <ctrl-D> works too.")
   interQuit = interApp.Command("quit", "exit the program.")
+  waitCmd = interApp.Command("wait", "wait for all the pending jobs to finish, see jobs.")
+
+  jobsCmd = interApp.Command("jobs", "the context for jobs, the waits for services becoming stable, tasks starting and so on.")
+  listJobsCmd = jobsCmd.Command("list", "list running and recently finished jobs (the default).").Default()
+  cancelJobCmd = jobsCmd.Command("cancel", "stop waiting on a job, AWS carries on with what it was asked to do.")
+  cancelJobCmd.Arg("id", "Job id from jobs.").Required().IntVar(&jobIdArg)
+  waitJobCmd = jobsCmd.Command("wait", "wait for a job, or all of them, to finish.")
+  waitJobCmd.Arg("id", "Job id from jobs, all jobs if not given.").Default("0").IntVar(&jobIdArg)
+  sourceCmd = interApp.Command("source", "run the commands in a script file, see ecs-pilot run.")
+  sourceCmd.Arg("file", "Script to run.").Required().StringVar(&sourceFileArg)
+  sourceCmd.Arg("vars", "Script variables NAME=VALUE.").StringMapVar(&sourceVarsArg)
+  aliasCmd = interApp.Command("alias", "list aliases, or set one for this session with alias name=\"command words\".")
+  aliasCmd.Arg("definition", "name=\"command words\" to set, name= to remove.").StringVar(&aliasArg)
+  historyCmd = interApp.Command("history", "list earlier commands, !n runs number n again and !! the last one.")
+  historyCmd.Flag("cluster", "Only commands run on the current cluster.").BoolVar(&historyClusterArg)
+  historyCmd.Flag("grep", "Only commands matching this (case insensitive) pattern.").StringVar(&historyGrepArg)
+  historyCmd.Flag("count", "How many to show, 0 for all.").Default("50").IntVar(&historyCountArg)
+  auditCmd = interApp.Command("audit", "the context for the audit log of changes made with ecs-pilot.")
+  auditShowCmd = auditCmd.Command("show", "list entries from the local audit log (the default).").Default()
+  auditShowCmd.Flag("cluster", "Only changes to this cluster.").StringVar(&auditClusterArg)
+  auditShowCmd.Flag("user", "Only changes made by this user.").StringVar(&auditUserArg)
+  auditShowCmd.Flag("grep", "Only commands matching this (case insensitive) pattern.").StringVar(&auditGrepArg)
+  auditShowCmd.Flag("count", "How many to show, 0 for all.").Default("50").IntVar(&auditCountArg)
+  doctorCmd = interApp.Command("doctor", "the context for checking ecs-pilot can do its job.")
+  doctorPermissionsCmd = doctorCmd.Command("permissions", "check the current identity's IAM policies allow what ecs-pilot calls.")
+  doctorPermissionsCmd.Flag("for", "Check the features at this level and below: read, deploy or admin.").Default(doctor.AdminLevel).EnumVar(&permissionLevelArg, doctor.Levels...)
 
   useClusterCmd = interApp.Command("use", "Set the cluster use as default.")
   useClusterCmd.Arg("cluster-name", "New default cluster.").Required().Action(setCurrent).StringVar(&clusterNameArg)
@@ -154,48 +268,112 @@ func init() {
   // Cluster Commands
   interCluster = interApp.Command("cluster", "the context for cluster commands")
   createCluster = interCluster.Command("create", "create a new cluster.")
-  createCluster.Arg("cluster-name", "the name of the cluster to create.").Required().Action(setCurrent).StringVar(&clusterNameArg)
+  createCluster.Arg("cluster-name", "the name of the cluster to create.").Required().StringVar(&clusterNameArg)
 
-  clusterUseCmd := interCluster.Command("use", "start using the named cluster in future commands.")
+  clusterUseCmd = interCluster.Command("use", "start using the named cluster in future commands.")
   clusterUseCmd.Arg("cluster-name", "the name of the cluster you want to start using.").Required().Action(setCurrent).StringVar(&clusterNameArg)
 
-  deleteCluster = interCluster.Command("delete", "delete a cluster.")
-  deleteCluster.Arg("cluster=name", "the name of the cluster to delete.").Required().Action(setCurrent).StringVar(&clusterNameArg)
+  deleteCluster = interCluster.Command("delete", "delete a cluster, after typing its name again.")
+  deleteCluster.Flag("force", "Needed to delete a protected cluster.").BoolVar(&forceArg)
+  deleteCluster.Arg("cluster-name", "the name of the cluster to delete.").Required().Action(setCurrent).StringVar(&clusterNameArg)
 
   interListClusters = interCluster.Command("list", "list the clusters")
   interDescribeCluster = interCluster.Command("describe", "Show the details of a particular cluster.")
+  interDescribeCluster.Flag("window", "Window for CloudWatch utilization, eg. 1h or 30m.").Default("1h").DurationVar(&metricsWindowArg)
   interDescribeCluster.Arg("cluster-name", "Short name of cluster to desecribe.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
+  rollInstancesCmd = interCluster.Command("roll-instances", "Replace every container instance in the cluster, a batch at a time.")
+  rollInstancesCmd.Flag("batch", "Number of instances to replace at once.").Default("1").IntVar(&rollBatchArg)
+  rollInstancesCmd.Flag("ami", "AMI for the replacements, defaults to the AMI of the instance being replaced.").StringVar(&rollAmiArg)
+  rollInstancesCmd.Flag("restart", "Discard an interrupted roll and start over.").BoolVar(&rollRestartArg)
+  rollInstancesCmd.Flag("force", "Needed to roll the instances of a protected cluster.").BoolVar(&forceArg)
+  rollInstancesCmd.Arg("cluster-name", "Short name of cluster to roll.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  fitClusterCmd = interCluster.Command("fit", "Simulate placing copies of a task definition on the cluster's instances.")
+  fitClusterCmd.Flag("count", "Number of copies to place, defaults to as many as will fit.").Default("0").IntVar(&fitCountArg)
+  fitClusterCmd.Arg("task-definition", "Task definition to fit.").Required().StringVar(&taskDefinitionArnArg)
+  resolvesRef(fitClusterCmd, taskDefinitionRefs, &taskDefinitionArnArg)
+  fitClusterCmd.Arg("cluster-name", "Short name of cluster.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  clusterCostCmd = interCluster.Command("cost", "Estimate the cluster's cost by instance, service and task.")
+  clusterCostCmd.Flag("json", "Print the report as JSON.").BoolVar(&costJsonArg)
+  clusterCostCmd.Arg("cluster-name", "Short name of cluster.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  clusterEventsCmd = interCluster.Command("events", "Show the events of all the cluster's services, interleaved by time.")
+  addEventFlags(clusterEventsCmd)
+  clusterEventsCmd.Arg("cluster-name", "Short name of cluster.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
   // Instance Commands
   instance = interApp.Command("instance", "the context for container instances commands.")
   interListContainerInstances = instance.Command("list", "list containers attached to a cluster.")
+  interListContainerInstances.Flag("window", "Window for CloudWatch utilization, eg. 1h or 30m.").Default("1h").DurationVar(&metricsWindowArg)
   interListContainerInstances.Arg("cluster-name", "Short name of cluster to look for instances in").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interDescribeContainerInstance = instance.Command("describe", "deatils assocaited with a container instance")
-  interDescribeContainerInstance.Arg("instance-arn", "ARN of the container instance").Required().StringVar(&interContainerArn)
+  interDescribeContainerInstance.Arg("instance-arn", "Container instance: ARN, unique ARN prefix or #n from instance list.").Required().StringVar(&interContainerArn)
+  resolvesRef(interDescribeContainerInstance, instanceRefs, &interContainerArn)
   interDescribeContainerInstance.Arg("cluster-name", "Short name of cluster for the instance").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interDescribeAllContainerInstances = instance.Command("describe-all", "details for all conatiners instances in a cluster.")
   interDescribeAllContainerInstances.Arg("cluster-name", "Short name of cluster for instances").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interCreateContainerInstance = instance.Command("create", "start up a new instance for a cluster")
+  interCreateContainerInstance.Flag("template", "Launch template from the config file.").StringVar(&launchTemplateArg)
+  interCreateContainerInstance.Flag("type", "EC2 instance type.").StringVar(&launchArgs.InstanceType)
+  interCreateContainerInstance.Flag("ami", "AMI to launch.").StringVar(&launchArgs.Ami)
+  interCreateContainerInstance.Flag("latest-ami", "Look up the latest ECS optimized AMI.").BoolVar(&launchArgs.LatestAmi)
+  interCreateContainerInstance.Flag("key", "EC2 key pair name.").StringVar(&launchArgs.KeyName)
+  interCreateContainerInstance.Flag("subnet", "Subnet to launch into.").StringVar(&launchArgs.Subnet)
+  interCreateContainerInstance.Flag("security-group", "Security group id, repeat for more.").StringsVar(&launchArgs.SecurityGroups)
+  interCreateContainerInstance.Flag("iam-profile", "IAM instance profile name or ARN.").StringVar(&launchArgs.IamProfile)
+  interCreateContainerInstance.Flag("spot", "Launch spot rather than on-demand instances.").BoolVar(&launchArgs.Spot)
+  interCreateContainerInstance.Flag("tag", "Extra instance tag KEY=VALUE.").StringMapVar(&launchArgs.Tags)
+  interCreateContainerInstance.Flag("agent-config", "Extra ECS agent config KEY=VALUE for /etc/ecs/ecs.config.").StringMapVar(&launchArgs.AgentConfig)
+  interCreateContainerInstance.Flag("count", "Number of instances to launch.").Default("1").Int64Var(&launchCountArg)
   interCreateContainerInstance.Arg("cluster-name", "Short name of cluster to for new instance.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interTerminateContainerInstance = instance.Command("terminate", "stop a container instnace.")
-  interTerminateContainerInstance.Arg("instance-arn", "ARN of the container instance to terminate.").Required().StringVar(&interContainerArn)
+  interTerminateContainerInstance.Flag("force", "Needed to terminate an instance on a protected cluster.").BoolVar(&forceArg)
+  interTerminateContainerInstance.Flag("drain", "Drain the instance and wait for service tasks to move before terminating.").BoolVar(&drainArg)
+  interTerminateContainerInstance.Arg("instance-arn", "Container instance to terminate: ARN, unique ARN prefix or #n from instance list.").Required().StringVar(&interContainerArn)
+  resolvesRef(interTerminateContainerInstance, instanceRefs, &interContainerArn)
   interTerminateContainerInstance.Arg("cluster-name", "Short name of cluster for instance to stop").Required().Action(setCurrent).StringVar(&clusterNameArg)
 
+  drainContainerInstanceCmd = instance.Command("drain", "set a container instance to DRAINING and wait for service tasks to move.")
+  drainContainerInstanceCmd.Arg("instance-arn", "Container instance to drain: ARN, unique ARN prefix or #n from instance list.").Required().StringVar(&interContainerArn)
+  resolvesRef(drainContainerInstanceCmd, instanceRefs, &interContainerArn)
+  drainContainerInstanceCmd.Arg("cluster-name", "Short name of cluster for the instance.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+
+  // Capacity Commands
+  capacityCmd = interApp.Command("capacity", "the context for capacity providers and their Auto Scaling groups.")
+  listCapacityCmd = capacityCmd.Command("list", "list a cluster's capacity providers and Auto Scaling groups.")
+  listCapacityCmd.Arg("cluster-name", "Short name of cluster.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  describeCapacityCmd = capacityCmd.Command("describe", "details of a capacity provider and its Auto Scaling group.")
+  describeCapacityCmd.Arg("provider-name", "Name of the capacity provider.").Required().StringVar(&capacityProviderArg)
+
+  setCapacityCmd = capacityCmd.Command("set", "change a capacity provider's Auto Scaling group and managed scaling.")
+  setCapacityCmd.Flag("min", "Minimum size of the Auto Scaling group.").Default("-1").Int64Var(&capacityArgs.Min)
+  setCapacityCmd.Flag("max", "Maximum size of the Auto Scaling group.").Default("-1").Int64Var(&capacityArgs.Max)
+  setCapacityCmd.Flag("desired", "Desired capacity of the Auto Scaling group.").Default("-1").Int64Var(&capacityArgs.Desired)
+  setCapacityCmd.Flag("target-capacity", "Managed scaling target capacity percent.").Default("0").Int64Var(&capacityArgs.TargetCapacity)
+  setCapacityCmd.Flag("scale-in-protection", "Protect the group's instances from scale in.").EnumVar(&capacityArgs.Protection, protectionEnabled, protectionDisabled)
+  setCapacityCmd.Arg("provider-name", "Name of the capacity provider.").Required().StringVar(&capacityProviderArg)
 
   // Task Commands
   interTask = interApp.Command("task", "the context for task commands.")
   interListTasks = interTask.Command("list", "the context for listing tasks")
+  interListTasks.Flag("stopped", "List recently stopped tasks rather than running ones.").BoolVar(&stoppedArg)
+  interListTasks.Flag("since", "With --stopped, how far back to look, eg. 30m.").Default("1h").DurationVar(&sinceArg)
   interListTasks.Arg("cluster-name", "Short name of cluster with tasks to list.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   statusTasks = interTask.Command("status", "the context for listing tasks")
   statusTasks.Arg("cluster-name", "Short name of cluster with tasks to list.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interDescribeTask = interTask.Command("describe", "Details assocaited with a running task.")
-  interDescribeTask.Arg("task-arn", "Arn for the task to describe.").Required().StringVar(&interTaskArn)
+  interDescribeTask.Arg("task-arn", "Task to describe: ARN, unique ARN prefix or #n from task list.").Required().StringVar(&interTaskArn)
+  resolvesRef(interDescribeTask, taskRefs, &interTaskArn)
   interDescribeTask.Arg("cluster-name", "Short ARN for the cluster where this task executes.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   interDescribeAllTasks = interTask.Command("describe-all", "describe all the tasks associatd with a cluster.")
@@ -203,13 +381,23 @@ func init() {
 
   interRunTask = interTask.Command("run", "Run a new task.")
   interRunTask.Arg("task-definition", "The definition of the task to run.").Required().StringVar(&taskDefinitionArnArg)
+  resolvesRef(interRunTask, taskDefinitionRefs, &taskDefinitionArnArg)
   interRunTask.Arg("cluster-name", "short name of the cluster to run the task on.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
-  interRunTask.Arg("environment", "Key values for the container environment.").StringMapVar(&taskEnv)
+  interRunTask.Arg("environment", "Key values for the container environment, KEY=@file reads the value from file.").StringMapVar(&taskEnv)
+  addTaskNetworkFlags(interRunTask)
 
   interStopTask = interTask.Command("stop", "Stop a task.")
-  interStopTask.Arg("task-arn", "ARN of the task to stop (from task list)").Required().StringVar(&interTaskArn)
+  interStopTask.Flag("force", "Needed to stop a task on a protected cluster.").BoolVar(&forceArg)
+  interStopTask.Arg("task-arn", "Task to stop: ARN, unique ARN prefix or #n from task list.").Required().StringVar(&interTaskArn)
+  resolvesRef(interStopTask, taskRefs, &interTaskArn)
   interStopTask.Arg("cluster-name", "short name of the cluster the task is running on.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
+  whyTaskCmd = interTask.Command("why", "Why a task stopped: stop reason, exit codes and the end of its logs.")
+  whyTaskCmd.Flag("lines", "Number of log lines to show for each container.").Default("20").Int64Var(&logLinesArg)
+  whyTaskCmd.Arg("task-arn", "Task: ARN, unique ARN prefix or #n from task list --stopped.").Required().StringVar(&interTaskArn)
+  resolvesRef(whyTaskCmd, taskRefs, &interTaskArn)
+  whyTaskCmd.Arg("cluster-name", "short name of the cluster the task ran on.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
   // Service Commands
   serviceCmd = interApp.Command("service", "the context for service commands.")
 
@@ -217,34 +405,91 @@ func init() {
   listServicesCmd.Arg("cluster-name", "Cluster where we'll find the services.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   describeServiceCmd = serviceCmd.Command("describe", "Print details about a service.")
+  describeServiceCmd.Flag("window", "Window for CloudWatch utilization, eg. 1h or 30m.").Default("1h").DurationVar(&metricsWindowArg)
   describeServiceCmd.Arg("service-name", "Name of service to describe.").Required().StringVar(&serviceNameArg)
+  resolvesRef(describeServiceCmd, serviceRefs, &serviceNameArg)
   describeServiceCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
+  serviceEventsCmd = serviceCmd.Command("events", "Show a service's events.")
+  addEventFlags(serviceEventsCmd)
+  serviceEventsCmd.Arg("service-name", "Name of the service.").Required().StringVar(&serviceNameArg)
+  resolvesRef(serviceEventsCmd, serviceRefs, &serviceNameArg)
+  serviceEventsCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
   createServiceCmd = serviceCmd.Command("create", "Create a new service.")
   createServiceCmd.Arg("service-name", "Name of new service.").Required().StringVar(&serviceNameArg)
   createServiceCmd.Arg("task-definition", "Task definition for new service.").Required().StringVar(&taskDefinitionArnArg)
+  resolvesRef(createServiceCmd, taskDefinitionRefs, &taskDefinitionArnArg)
   createServiceCmd.Arg("instance-count", "Number of instances of task definition to run in new service.").Required().Int64Var(&instanceCountArg)
   createServiceCmd.Arg("cluster-name", "Cluster for the new service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+  addTaskNetworkFlags(createServiceCmd)
 
   restartServiceCmd = serviceCmd.Command("restart", "Restart the service.")
   restartServiceCmd.Arg("service-name", "Name of service to restart.").Required().StringVar(&serviceNameArg)
+  resolvesRef(restartServiceCmd, serviceRefs, &serviceNameArg)
   restartServiceCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
   updateServiceDesiredCountCmd = serviceCmd.Command("update-count", "Update the desired instance count for the service.")
   updateServiceDesiredCountCmd.Arg("service-name", "Name of service to update.").Required().StringVar(&serviceNameArg)
+  resolvesRef(updateServiceDesiredCountCmd, serviceRefs, &serviceNameArg)
   updateServiceDesiredCountCmd.Arg("instance-count", "Number of instances of task definition to run in updated service.").Required().Int64Var(&instanceCountArg)
   updateServiceDesiredCountCmd.Arg("cluster-name", "Cluster for the update service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
-  deleteServiceCmd = serviceCmd.Command("delete", "Delete a service.")
+  deleteServiceCmd = serviceCmd.Command("delete", "Delete a service, after typing its name again.")
+  deleteServiceCmd.Flag("force", "Needed to delete a service on a protected cluster.").BoolVar(&forceArg)
   deleteServiceCmd.Arg("service-name", "Name of service to delete.").Required().StringVar(&serviceNameArg)
+  resolvesRef(deleteServiceCmd, serviceRefs, &serviceNameArg)
   deleteServiceCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
 
+  exportServiceCmd = serviceCmd.Command("export", "Write the service and its task definition out as re-registrable JSON.")
+  exportServiceCmd.Flag("out", "Directory to write the JSON files to.").Short('o').Default(".").StringVar(&exportDirArg)
+  exportServiceCmd.Arg("service-name", "Name of service to export.").Required().StringVar(&serviceNameArg)
+  resolvesRef(exportServiceCmd, serviceRefs, &serviceNameArg)
+  exportServiceCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  autoScaleCmd = serviceCmd.Command("autoscale", "Manage target tracking auto scaling for a service.")
+  showAutoScaleCmd = autoScaleCmd.Command("show", "Show the scalable target and policies for a service.")
+  showAutoScaleCmd.Arg("service-name", "Name of the service.").Required().StringVar(&serviceNameArg)
+  resolvesRef(showAutoScaleCmd, serviceRefs, &serviceNameArg)
+  showAutoScaleCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  setAutoScaleCmd = autoScaleCmd.Command("set", "Set the task count limits and CPU/memory targets for a service.")
+  setAutoScaleCmd.Flag("min", "Minimum number of tasks.").Default("-1").Int64Var(&minCountArg)
+  setAutoScaleCmd.Flag("max", "Maximum number of tasks.").Default("-1").Int64Var(&maxCountArg)
+  setAutoScaleCmd.Flag("cpu-target", "Target average CPU utilization percent.").Default("0").Float64Var(&cpuTargetArg)
+  setAutoScaleCmd.Flag("memory-target", "Target average memory utilization percent.").Default("0").Float64Var(&memoryTargetArg)
+  setAutoScaleCmd.Arg("service-name", "Name of the service.").Required().StringVar(&serviceNameArg)
+  resolvesRef(setAutoScaleCmd, serviceRefs, &serviceNameArg)
+  setAutoScaleCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  removeAutoScaleCmd = autoScaleCmd.Command("remove", "Remove the scaling policies and scalable target from a service.")
+  removeAutoScaleCmd.Arg("service-name", "Name of the service.").Required().StringVar(&serviceNameArg)
+  resolvesRef(removeAutoScaleCmd, serviceRefs, &serviceNameArg)
+  removeAutoScaleCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  deployServiceCmd = serviceCmd.Command("deploy", "Deploy a new task definition to a service.")
+  deployServiceCmd.Flag("application", "CodeDeploy application (found from the service if not given).").StringVar(&deployAppArg)
+  deployServiceCmd.Flag("deployment-group", "CodeDeploy deployment group (found from the service if not given).").StringVar(&deployGroupArg)
+  startDeployCmd = deployServiceCmd.Command("start", "Start a deployment (the default).").Default()
+  startDeployCmd.Flag("strategy", "Deployment strategy: rolling or bluegreen (CodeDeploy).").Default(rollingStrategy).EnumVar(&deployStrategyArg, rollingStrategy, blueGreenStrategy)
+  startDeployCmd.Arg("service-name", "Name of service to deploy to.").Required().StringVar(&serviceNameArg)
+  resolvesRef(startDeployCmd, serviceRefs, &serviceNameArg)
+  startDeployCmd.Arg("task-definition", "Task definition to deploy.").Required().StringVar(&taskDefinitionArnArg)
+  resolvesRef(startDeployCmd, taskDefinitionRefs, &taskDefinitionArnArg)
+  startDeployCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
+  rollbackDeployCmd = deployServiceCmd.Command("rollback", "Roll back the deployment in progress.")
+  rollbackDeployCmd.Arg("service-name", "Name of service to roll back.").Required().StringVar(&serviceNameArg)
+  resolvesRef(rollbackDeployCmd, serviceRefs, &serviceNameArg)
+  rollbackDeployCmd.Arg("cluster-name", "Cluster for the service.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
   // Task Definition.
   interTaskDefinition = interApp.Command("task-definition", "the context for task definitions.")
   interListTaskDefinitions = interTaskDefinition.Command("list", "list the existing task definntions.")
 
   interDescribeTaskDefinition = interTaskDefinition.Command("describe", "Describe all the registered task definitions.")
-  interDescribeTaskDefinition.Arg("task-definition-arn", "arn of task definition to describe.").Required().StringVar(&taskDefinitionArnArg)
+  interDescribeTaskDefinition.Arg("task-definition-arn", "Task definition to describe, a family alone is its latest revision.").Required().StringVar(&taskDefinitionArnArg)
+  resolvesRef(interDescribeTaskDefinition, taskDefinitionRefs, &taskDefinitionArnArg)
 
   registerTaskDefinition = interTaskDefinition.Command("register", "Register a task definition.") 
   registerTaskDefinition.Arg("config", "Configuration desecription for task definition.").Required().StringVar(&taskConfigFileName)
@@ -262,9 +507,22 @@ func init() {
   listImageCmd = imageCmd.Command("list", "list the images for the give repository.")
   listImageCmd.Arg("repository", "Image repository to find list of images.").Required().StringVar(&imageRepositoryArg)
 
+  // Apply
+  applyCmd = interApp.Command("apply", "Reconcile a directory of service and task definition specs against a cluster.")
+  applyCmd.Flag("prune", "Delete services on the cluster that aren't in the specs.").BoolVar(&pruneArg)
+  applyCmd.Flag("force", "Needed to prune services on a protected cluster.").BoolVar(&forceArg)
+  applyCmd.Arg("spec-dir", "Directory of *.service.json and *.task.json specs (see service export).").Required().StringVar(&specDirArg)
+  applyCmd.Arg("cluster-name", "Cluster to apply the specs to.").Default(defaultCluster).Action(setCurrent).StringVar(&clusterNameArg)
+
   // Serer
   serverCmd = interApp.Command("server", "Run a server front end.")
   serverCmd.Arg("address", "Address to listen for HTTP connections.").Default("127.0.0.1:8080").StringVar(&serverAddressArg)
+
+  audited(createCluster, deleteCluster, rollInstancesCmd, setCapacityCmd,
+    createServiceCmd, restartServiceCmd, updateServiceDesiredCountCmd, deleteServiceCmd,
+    setAutoScaleCmd, removeAutoScaleCmd, startDeployCmd, rollbackDeployCmd,
+    interRunTask, interStopTask, registerTaskDefinition,
+    interCreateContainerInstance, interTerminateContainerInstance, drainContainerInstanceCmd, applyCmd)
 }
 
 func doICommand(line string, ecsSvc *ecs.ECS, ec2Svc *ec2.EC2, awsConfig *aws.Config, sess *session.Session) (err error) {
@@ -273,53 +531,145 @@ func doICommand(line string, ecsSvc *ecs.ECS, ec2Svc *ec2.EC2, awsConfig *aws.Co
   // through doICommand. So we reset them here.
   interTestString = []string{}
   taskEnv = make(map[string]string)
+  sourceVarsArg = make(map[string]string)
+  aliasArg = ""
+  historyClusterArg = false
+  historyGrepArg = ""
+  auditClusterArg = ""
+  auditUserArg = ""
+  auditGrepArg = ""
+  declined = false
+  taskNetworkArgs = taskNetworkOptions{}
   sortByLastUpdate = false
   sortByCreatedAt = false
+  pruneArg = false
+  drainArg = false
+  forceArg = false
+  yesArg = false
+  dryRunArg = false
+  stoppedArg = false
+  eventsSinceArg = 0
+  eventsGrepArg = ""
+  eventsFollowArg = false
+  costJsonArg = false
+  launchTemplateArg = ""
+  launchArgs = launchTemplate{Tags: make(map[string]string), AgentConfig: make(map[string]string)}
+  rollAmiArg = ""
+  rollRestartArg = false
+  capacityArgs.Protection = ""
+  deployAppArg = ""
+  deployGroupArg = ""
 
   // Prepare a line for parsing
   line = strings.TrimRight(line, "\n")
-  fields := []string{}
-  fields = append(fields, strings.Fields(line)...)
+  fields, err := tokenize(line)
+  if err == nil { fields, err = expandAlias(fields, getAliases()) }
+  if err != nil {
+    fmt.Printf("Command error: %s.\n", err)
+    return errBadCommand
+  }
   if len(fields) <= 0 {
     return nil
   }
 
+  // A cluster-name argument is for this command only, use changes the cluster for good.
+  // A use in a sourced script does too, as if it had been typed at the prompt.
+  previousCluster := currentCluster
+  commandCluster = currentCluster
   command, err := interApp.Parse(fields)
+  switch command {
+  case useClusterCmd.FullCommand(), clusterUseCmd.FullCommand():
+    if err == nil { printProtectedBanner(currentCluster) }
+  case sourceCmd.FullCommand():
+  default:
+    defer func() {
+      commandCluster = currentCluster
+      currentCluster = previousCluster
+    }()
+  }
 
   if err != nil {
     fmt.Printf("Command error: %s.\nType help for a list of commands.\n", err)
-    return nil
+    return errBadCommand
   } else {
+      // Sourced commands make their own dry run sessions.
+      baseSess := sess
+      if dryRun() { sess = dryRunSession(sess) }
+      // #n, prefixes and bare families to what they refer to.
+      if err = resolveRefs(command, currentCluster, sess); err != nil { return err }
+
       switch command {
       case debugCmd.FullCommand(): err = doDebug()
       case interVerbose.FullCommand(): err = doVerbose()
       case interExit.FullCommand(): err = doQuit(sess)
       case interQuit.FullCommand(): err = doQuit(sess)
+      case waitCmd.FullCommand(): err = doWaitJobs(0)
+      case listJobsCmd.FullCommand(): err = doListJobs()
+      case cancelJobCmd.FullCommand(): err = doCancelJob(jobIdArg)
+      case waitJobCmd.FullCommand(): err = doWaitJobs(jobIdArg)
+      case aliasCmd.FullCommand(): err = doAlias(aliasArg)
+      case historyCmd.FullCommand(): err = doHistory(historyClusterArg, historyGrepArg, historyCountArg)
+      case auditShowCmd.FullCommand(): err = doAuditShow(auditClusterArg, auditUserArg, auditGrepArg, auditCountArg)
+      case doctorPermissionsCmd.FullCommand(): err = doDoctorPermissions(permissionLevelArg, sess)
+      case sourceCmd.FullCommand():
+        if dryRunArg && !assumeDryRun {
+          assumeDryRun = true
+          defer func() { assumeDryRun = false }()
+        }
+        err = runScriptFile(sourceFileArg, sourceVarsArg, func(line string) (error) {
+          return doICommand(line, ecsSvc, ec2Svc, awsConfig, baseSess)
+        })
+        commandCluster = currentCluster
 
-      case createCluster.FullCommand(): err = doCreateCluster(sess)
-      case deleteCluster.FullCommand(): err = doDeleteCluster(sess)
+      case createCluster.FullCommand(): err = doCreateCluster(clusterNameArg, sess)
+      case deleteCluster.FullCommand(): err = doDeleteCluster(currentCluster, forceArg, sess)
       case interListClusters.FullCommand(): err = doListClusters(sess)
-      case interDescribeCluster.FullCommand(): err = doDescribeCluster(sess)
-
-      case interListTasks.FullCommand(): err = doListTasks(currentCluster, sess)
+      case interDescribeCluster.FullCommand(): err = doDescribeCluster(metricsWindowArg, sess)
+      case rollInstancesCmd.FullCommand(): err = doRollInstances(currentCluster, rollBatchArg, rollAmiArg, rollRestartArg, forceArg, sess)
+      case fitClusterCmd.FullCommand(): err = doFitTaskDefinition(taskDefinitionArnArg, currentCluster, fitCountArg, sess)
+      case clusterCostCmd.FullCommand(): err = doClusterCost(currentCluster, costJsonArg, sess)
+
+      case listCapacityCmd.FullCommand(): err = doListCapacity(currentCluster, sess)
+      case describeCapacityCmd.FullCommand(): err = doDescribeCapacity(capacityProviderArg, sess)
+      case setCapacityCmd.FullCommand(): err = doSetCapacity(capacityProviderArg, capacityArgs, sess)
+
+      case interListTasks.FullCommand():
+        if stoppedArg {
+          err = doListStoppedTasks(currentCluster, sinceArg, sess)
+        } else {
+          err = doListTasks(currentCluster, sess)
+        }
       case statusTasks.FullCommand(): err = doStatusTasks(currentCluster, sess)
+      case whyTaskCmd.FullCommand(): err = doWhyTask(interTaskArn, currentCluster, logLinesArg, sess)
       case interDescribeTask.FullCommand(): err = doDescribeTask(sess)
       case interDescribeAllTasks.FullCommand(): err = doDescribeAllTasks(sess)
       case interRunTask.FullCommand(): err = doRunTask(sess)
-      case interStopTask.FullCommand(): err = doStopTask(sess)
+      case interStopTask.FullCommand(): err = doStopTask(forceArg, sess)
 
       case listServicesCmd.FullCommand(): err = doListServices(currentCluster, sess)
-      case describeServiceCmd.FullCommand(): err = doDescribeService(serviceNameArg, currentCluster, sess)
-      case createServiceCmd.FullCommand(): err = doCreateService(serviceNameArg, taskDefinitionArnArg, currentCluster, instanceCountArg, sess)
+      case serviceEventsCmd.FullCommand(): err = doServiceEvents(serviceNameArg, currentCluster, eventsSinceArg, eventsGrepArg, eventsFollowArg, sess)
+      case clusterEventsCmd.FullCommand(): err = doServiceEvents("", currentCluster, eventsSinceArg, eventsGrepArg, eventsFollowArg, sess)
+      case describeServiceCmd.FullCommand(): err = doDescribeService(serviceNameArg, currentCluster, metricsWindowArg, sess)
+      case createServiceCmd.FullCommand(): err = doCreateService(serviceNameArg, taskDefinitionArnArg, currentCluster, instanceCountArg, 
+        taskNetworkArgs.withLists(), sess)
       case restartServiceCmd.FullCommand(): err = doRestartService(serviceNameArg, currentCluster, sess)
       case updateServiceDesiredCountCmd.FullCommand(): err = doUpdateServiceDesiredCount(serviceNameArg, currentCluster, instanceCountArg, sess)
-      case deleteServiceCmd.FullCommand(): err = doDeleteService(serviceNameArg, currentCluster, sess)
-
-      case interListContainerInstances.FullCommand(): err = doListContainerInstances(sess)
+      case deleteServiceCmd.FullCommand(): err = doDeleteService(serviceNameArg, currentCluster, forceArg, sess)
+      case exportServiceCmd.FullCommand(): err = doExportService(serviceNameArg, currentCluster, exportDirArg, sess)
+      case showAutoScaleCmd.FullCommand(): err = doShowServiceAutoScaling(serviceNameArg, currentCluster, sess)
+      case setAutoScaleCmd.FullCommand(): err = doSetServiceAutoScaling(serviceNameArg, currentCluster,
+        minCountArg, maxCountArg, cpuTargetArg, memoryTargetArg, sess)
+      case removeAutoScaleCmd.FullCommand(): err = doRemoveServiceAutoScaling(serviceNameArg, currentCluster, sess)
+      case startDeployCmd.FullCommand(): err = doDeployService(serviceNameArg, taskDefinitionArnArg, currentCluster,
+        deployStrategyArg, deployAppArg, deployGroupArg, sess)
+      case rollbackDeployCmd.FullCommand(): err = doRollbackService(serviceNameArg, currentCluster, deployAppArg, deployGroupArg, sess)
+
+      case interListContainerInstances.FullCommand(): err = doListContainerInstances(metricsWindowArg, sess)
       case interDescribeContainerInstance.FullCommand(): err = doDescribeContainerInstance(sess)
       case interDescribeAllContainerInstances.FullCommand(): err = doDescribeAllContainerInstances(sess)
-      case interCreateContainerInstance.FullCommand(): err = doCreateContainerInstance(sess)
-      case interTerminateContainerInstance.FullCommand(): err = doTerminateContainerInstance(sess)
+      case interCreateContainerInstance.FullCommand(): err = doCreateContainerInstance(launchTemplateArg, launchArgs, launchCountArg, sess)
+      case interTerminateContainerInstance.FullCommand(): err = doTerminateContainerInstance(forceArg, sess)
+      case drainContainerInstanceCmd.FullCommand(): err = doDrainContainerInstance(sess)
 
       case interListTaskDefinitions.FullCommand(): err = doListTaskDefinitions(sess)
       case interDescribeTaskDefinition.FullCommand(): err = doDescribeTaskDefinition(sess)
@@ -332,12 +682,34 @@ func doICommand(line string, ecsSvc *ecs.ECS, ec2Svc *ec2.EC2, awsConfig *aws.Co
 
       case serverCmd.FullCommand(): err = doServer(serverAddressArg, sess, false)
 
+      case applyCmd.FullCommand(): err = doApply(specDirArg, currentCluster, pruneArg, yesArg || assumeYes, forceArg, sess)
+
+    }
+    if dryRun() && isDryRunError(err) {
+      fmt.Printf("%sDry run, nothing was changed.%s\n", successColor, resetColor)
+      err = nil
     }
+    auditCluster := currentCluster
+    if command == createCluster.FullCommand() { auditCluster = clusterNameArg }
+    recordAudit(command, line, auditCluster, err)
   }
   return err
 }
 
 
+func addTaskNetworkFlags(cmd *kingpin.CmdClause) {
+  cmd.Flag("launch-type", "Launch type: EC2 or FARGATE.").EnumVar(&taskNetworkArgs.LaunchType, ecs.LaunchTypeEc2, ecs.LaunchTypeFargate)
+  cmd.Flag("subnets", "Subnets for awsvpc networking (repeat or comma separate).").StringsVar(&taskNetworkArgs.Subnets)
+  cmd.Flag("security-groups", "Security groups for awsvpc networking (repeat or comma separate).").StringsVar(&taskNetworkArgs.SecurityGroups)
+  cmd.Flag("assign-public-ip", "Give the task's network interface a public address.").BoolVar(&taskNetworkArgs.AssignPublicIp)
+}
+
+func addEventFlags(cmd *kingpin.CmdClause) {
+  cmd.Flag("since", "Only events this recent, eg. 30m.").DurationVar(&eventsSinceArg)
+  cmd.Flag("grep", "Only events with messages matching this (case insensitive) pattern.").StringVar(&eventsGrepArg)
+  cmd.Flag("follow", "Keep polling for new events until interrupted.").Short('f').BoolVar(&eventsFollowArg)
+}
+
 // TODO: finish the thought.
 // map[string]interface{}{
 //   "cluster-name": currentCluster
@@ -354,16 +726,11 @@ func setCurrent(pc *kingpin.ParseContext) (error) {
       fc := c.(*kingpin.ArgClause)
       if fc.Model().Name == "cluster-name" {
         nc := *pe.Value
+        // Carrying on with the current cluster could do to it what was meant for another.
         there, err := cCache.Contains(nc, currentSession)
-        if there {
-          currentCluster = nc
-        } else {
-          if err != nil {
-            fmt.Printf("Failed to find cluster: %s\n", err)
-          } else {
-            fmt.Printf("Failed to find cluster \"%s\".\n", nc)
-          }
-        }
+        if err != nil { return fmt.Errorf("Failed to find cluster: %s", err) }
+        if !there { return fmt.Errorf("There's no cluster \"%s\"", nc) }
+        currentCluster = nc
       }
     }
   }
@@ -424,7 +791,16 @@ func configureLogs() {
   server.SetLogLevel(l)
 }
 
+// Pending jobs are lost on quitting, so check first. Quitting a script
+// only ends the script, which waits for its jobs, so there's no need there.
 func doQuit(sess *session.Session) (error) {
+  if running := jobs.running(); len(running) > 0 && scriptDepth == 0 {
+    fmt.Printf("%sThere are %d jobs still pending, they'll be lost:%s\n", warnColor, len(running), resetColor)
+    for _, j := range running {
+      fmt.Printf("  %d. %s on %s (%s)\n", j.Id, j.Description, j.Cluster, shortDurationString(time.Since(j.Started)))
+    }
+    if !confirm("Quit anyway?") { return nil }
+  }
   doListClusters(sess)
   return io.EOF
 }
@@ -433,18 +809,38 @@ func doTerminate(i int) {}
 
 func promptLoop(process func(string) (error)) (err error) {
   for moreCommands := true; moreCommands; {
-    prompt := fmt.Sprintf("%spilot [%s%s%s]:%s ", titleEmph, infoColor, currentCluster, titleEmph, resetColor)
+    jobCount := ""
+    if n := jobs.pending(); n == 1 {
+      jobCount = fmt.Sprintf(" %s(1 job)%s", warnColor, titleEmph)
+    } else if n > 1 {
+      jobCount = fmt.Sprintf(" %s(%d jobs)%s", warnColor, n, titleEmph)
+    }
+    clusterColor := infoColor
+    if isProtected(currentCluster) { clusterColor = failColor }
+    prompt := fmt.Sprintf("%spilot [%s%s%s]%s:%s ", titleEmph, clusterColor, currentCluster, titleEmph, jobCount, resetColor)
+    publishCompletionState()
     line, err := readline.Line(prompt)
     if err == io.EOF {
       moreCommands = false
     } else if err != nil {
       fmt.Printf("%sReadline Error: %s%s\n", failColor, err, resetColor)
     } else {
-      readline.AddHistory(line)
+      expanded, fromHistory, herr := expandHistory(line)
+      if herr != nil {
+        fmt.Printf("%s%s%s\n", failColor, herr, resetColor)
+        continue
+      }
+      if fromHistory {
+        fmt.Printf("%s%s%s\n", emphColor, expanded, resetColor)
+        line = expanded
+      }
+      if strings.TrimSpace(line) != "" { readline.AddHistory(line) }
       err = process(line)
+      // After, so commandCluster is the one it ran on.
+      if strings.TrimSpace(line) != "" { recordHistory(line, commandCluster) }
       if err == io.EOF {
         moreCommands = false
-      } else if err != nil {
+      } else if err != nil && err != errBadCommand {
         fmt.Printf("%sError: %s%s\n", failColor, err, resetColor)
       }
     }
@@ -453,11 +849,16 @@ func promptLoop(process func(string) (error)) (err error) {
 }
 
 // This gets called from the main program, presumably from the 'interactive' command on main's command line.
-func DoInteractive(sess *session.Session, defaultConfig *aws.Config) {
+// History is ours, in ~/.ecs-pilot/history, rather than readline's.
+func DoInteractive(profile string, sess *session.Session, defaultConfig *aws.Config) {
   currentSession = sess
+  currentProfile = profile
   ecs_svc := ecs.New(sess)
   ec2_svc := ec2.New(sess)
-  readline.SetHistoryPath("./.ecs-pilot_history")
+  readline.SetHistoryPath("")
+  recallHistory()
+  readline.SetAutoComplete(newPilotCompleter(interApp))
+  printProtectedBanner(currentCluster)
   xICommand := func(line string) (err error) {return doICommand(line, ecs_svc, ec2_svc, defaultConfig, sess)}
   err := promptLoop(xICommand)
   if err != nil {fmt.Printf("%sError exiting prompter: %s%s\n", failColor, err, resetColor)}
@@ -0,0 +1,234 @@
+package interactive
+
+import (
+  "context"
+  "fmt"
+  "os"
+  "os/signal"
+  "sort"
+  "sync"
+  "text/tabwriter"
+  "time"
+)
+
+// The states of a job.
+const (
+  jobRunning = "running"
+  jobDone = "done"
+  jobFailed = "failed"
+  jobCancelled = "cancelled"
+)
+
+// How long finished jobs stay in the jobs list.
+const finishedJobsKept = 30 * time.Minute
+
+// An asynchronous waiter, an awslib On* callback or a drain, started by a command.
+// Cancelling stops waiting: the callback's output is dropped and, where the waiting
+// is ours (draining), it stops. AWS carries on with whatever was asked of it.
+type job struct {
+  jobInfo
+  ctx context.Context
+  cancel context.CancelFunc
+  done chan struct{}
+  once sync.Once
+  mu *sync.Mutex
+}
+
+// What there is to know about a job, a copy of which is safe to read while it runs.
+type jobInfo struct {
+  Id int
+  Description string
+  Cluster string
+  Started time.Time
+  Finished time.Time
+  Status string
+  Err error
+}
+
+type jobManager struct {
+  mu sync.Mutex
+  nextId int
+  jobs map[int]*job
+}
+
+var jobs = newJobManager()
+
+func newJobManager() (*jobManager) {
+  return &jobManager{nextId: 1, jobs: make(map[int]*job)}
+}
+
+// Call before starting a waiter, and finish the job from its callback.
+func (m *jobManager) start(clusterName, description string) (*job) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  ctx, cancel := context.WithCancel(context.Background())
+  j := &job{ctx: ctx, cancel: cancel, done: make(chan struct{}), mu: &m.mu}
+  j.jobInfo = jobInfo{Id: m.nextId, Description: description, Cluster: clusterName, Started: time.Now(), Status: jobRunning}
+  m.jobs[j.Id] = j
+  m.nextId++
+  return j
+}
+
+// The first end, finishing or cancelling, is the one that counts.
+func (j *job) end(status string, err error) {
+  j.once.Do(func() {
+    j.mu.Lock()
+    j.Status, j.Err, j.Finished = status, err, time.Now()
+    j.mu.Unlock()
+    j.cancel()
+    close(j.done)
+  })
+}
+
+func (j *job) finish(err error) {
+  if err != nil {
+    j.end(jobFailed, err)
+  } else {
+    j.end(jobDone, nil)
+  }
+}
+
+// Callbacks check this and say nothing for a cancelled job.
+func (j *job) cancelled() (bool) {
+  j.mu.Lock()
+  defer j.mu.Unlock()
+  return j.Status == jobCancelled
+}
+
+func (m *jobManager) get(id int) (*job, error) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  j, ok := m.jobs[id]
+  if !ok { return nil, fmt.Errorf("There's no job %d", id) }
+  return j, nil
+}
+
+func (m *jobManager) cancel(id int) (*job, error) {
+  j, err := m.get(id)
+  if err != nil { return nil, err }
+  j.end(jobCancelled, nil)
+  return j, nil
+}
+
+// Running jobs first, then by id. Finished jobs are forgotten after a while.
+func (m *jobManager) list() ([]jobInfo) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  list := make([]jobInfo, 0, len(m.jobs))
+  for id, j := range m.jobs {
+    if j.Status != jobRunning && time.Since(j.Finished) > finishedJobsKept {
+      delete(m.jobs, id)
+      continue
+    }
+    list = append(list, j.jobInfo)
+  }
+  sort.Slice(list, func(i, k int) bool {
+    if (list[i].Status == jobRunning) != (list[k].Status == jobRunning) { return list[i].Status == jobRunning }
+    return list[i].Id < list[k].Id
+  })
+  return list
+}
+
+// By id.
+func (m *jobManager) running() ([]*job) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  running := make([]*job, 0)
+  for _, j := range m.jobs {
+    if j.Status == jobRunning { running = append(running, j) }
+  }
+  sort.Slice(running, func(i, k int) bool { return running[i].Id < running[k].Id })
+  return running
+}
+
+func (m *jobManager) pending() (int) {
+  return len(m.running())
+}
+
+// Blocks until the jobs are done, or until interrupt is closed or sent on.
+// Jobs started while waiting are waited for too. Returns false if interrupted.
+func (m *jobManager) wait(only *job, interrupt <-chan os.Signal) (bool) {
+  for {
+    running := m.running()
+    if only != nil { running = []*job{only} }
+    if len(running) == 0 { return true }
+    select {
+    case <-running[0].done:
+      if only != nil { return true }
+    case <-interrupt:
+      return false
+    }
+  }
+}
+
+// Waits for a job, or all of them if id is 0, until done or ^C.
+func doWaitJobs(id int) (error) {
+  var only *job
+  if id != 0 {
+    j, err := jobs.get(id)
+    if err != nil { return err }
+    only = j
+  } else if jobs.pending() == 0 {
+    fmt.Printf("Nothing pending.\n")
+    return nil
+  }
+
+  if only != nil {
+    fmt.Printf("%s%sWaiting for job %d: %s, ^C to stop waiting.%s\n", infoColor, nowString(), only.Id, only.Description, resetColor)
+  } else {
+    fmt.Printf("%s%sWaiting for %d jobs, ^C to stop waiting.%s\n", infoColor, nowString(), jobs.pending(), resetColor)
+  }
+  interrupt := make(chan os.Signal, 1)
+  signal.Notify(interrupt, os.Interrupt)
+  defer signal.Stop(interrupt)
+  if !jobs.wait(only, interrupt) {
+    fmt.Printf("\n%sStopped waiting, %d jobs still pending.%s\n", warnColor, jobs.pending(), resetColor)
+    return nil
+  }
+  if only != nil {
+    fmt.Printf("%s%sJob %d %s.%s\n", jobColor(only.Status), nowString(), only.Id, only.Status, resetColor)
+  } else {
+    fmt.Printf("%s%sNothing pending.%s\n", successColor, nowString(), resetColor)
+  }
+  return nil
+}
+
+func doCancelJob(id int) (error) {
+  j, err := jobs.cancel(id)
+  if err != nil { return err }
+  if j.Status != jobCancelled {
+    fmt.Printf("Job %d had already %s.\n", j.Id, map[string]string{jobDone: "finished", jobFailed: "failed"}[j.Status])
+    return nil
+  }
+  fmt.Printf("%sStopped waiting on job %d: %s.%s\n", warnColor, j.Id, j.Description, resetColor)
+  return nil
+}
+
+func doListJobs() (error) {
+  list := jobs.list()
+  if len(list) == 0 {
+    fmt.Printf("There are no jobs.\n")
+    return nil
+  }
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sId\tStatus\tCluster\tStarted\tTook\tJob\tError%s\n", titleColor, resetColor)
+  for _, j := range list {
+    took := shortDurationString(time.Since(j.Started))
+    if j.Status != jobRunning { took = shortDurationString(j.Finished.Sub(j.Started)) }
+    errString := ""
+    if j.Err != nil { errString = j.Err.Error() }
+    fmt.Fprintf(w, "%s%d\t%s\t%s\t%s\t%s\t%s\t%s%s\n", jobColor(j.Status), j.Id, j.Status, j.Cluster,
+      j.Started.Local().Format(humanTimeFormat), took, j.Description, errString, resetColor)
+  }
+  w.Flush()
+  return nil
+}
+
+func jobColor(status string) (string) {
+  switch status {
+  case jobFailed: return failColor
+  case jobCancelled: return warnColor
+  case jobDone: return successColor
+  }
+  return nullColor
+}
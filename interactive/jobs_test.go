@@ -0,0 +1,48 @@
+package interactive
+
+import(
+  "errors"
+  "os"
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+  m := newJobManager()
+  stable := m.start("prod", "service web stable")
+  running := m.start("prod", "task running")
+  stopped := m.start("prod", "task stopped")
+  assert.Equal(t, 3, m.pending())
+
+  running.finish(errors.New("stopped early"))
+  running.finish(nil)
+  assert.Equal(t, jobFailed, running.Status, "Only the first finish counts.")
+
+  _, err := m.cancel(stopped.Id)
+  assert.NoError(t, err)
+  assert.True(t, stopped.cancelled())
+  stopped.finish(nil)
+  assert.Equal(t, jobCancelled, stopped.Status, "A late callback doesn't undo cancelling.")
+  assert.Error(t, stopped.ctx.Err(), "Cancelling should cancel the context.")
+
+  _, err = m.cancel(99)
+  assert.Error(t, err)
+
+  list := m.list()
+  if assert.Len(t, list, 3) {
+    assert.Equal(t, stable.Id, list[0].Id, "Running jobs come first.")
+    assert.Equal(t, running.Id, list[1].Id)
+  }
+
+  assert.False(t, m.wait(nil, closedInterrupt()), "Should be interrupted while web isn't stable.")
+  go stable.finish(nil)
+  assert.True(t, m.wait(nil, nil))
+  assert.Equal(t, 0, m.pending())
+  assert.True(t, m.wait(running, nil), "Waiting on a finished job returns.")
+}
+
+func closedInterrupt() (chan os.Signal) {
+  c := make(chan os.Signal)
+  close(c)
+  return c
+}
@@ -0,0 +1,194 @@
+package interactive
+
+import (
+  "encoding/base64"
+  "fmt"
+  "sort"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ec2"
+  "github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+  defaultInstanceType = "t2.medium"
+  defaultInstanceProfile = "ecsInstanceRole"
+  latestEcsAmiParameter = "/aws/service/ecs/optimized-ami/amazon-linux-2/recommended/image_id"
+)
+
+// Launch settings from a named template in the config file and/or instance create flags.
+type launchTemplate struct {
+  InstanceType string `yaml:"instanceType"`
+  Ami string `yaml:"ami"`
+  LatestAmi bool `yaml:"latestAmi"`
+  KeyName string `yaml:"keyName"`
+  Subnet string `yaml:"subnet"`
+  SecurityGroups []string `yaml:"securityGroups"`
+  IamProfile string `yaml:"iamProfile"`
+  Spot bool `yaml:"spot"`
+  Tags map[string]string `yaml:"tags"`
+  AgentConfig map[string]string `yaml:"agentConfig"`
+}
+
+func (t *launchTemplate) isEmpty() (bool) {
+  return t.InstanceType == "" && t.Ami == "" && !t.LatestAmi && t.KeyName == "" && t.Subnet == "" &&
+    len(t.SecurityGroups) == 0 && t.IamProfile == "" && !t.Spot && len(t.Tags) == 0 && len(t.AgentConfig) == 0
+}
+
+// Settings in o replace those in t.
+func (t launchTemplate) merge(o *launchTemplate) (launchTemplate) {
+  if o.InstanceType != "" { t.InstanceType = o.InstanceType }
+  if o.Ami != "" { t.Ami = o.Ami; t.LatestAmi = false }
+  if o.LatestAmi { t.LatestAmi = true; t.Ami = "" }
+  if o.KeyName != "" { t.KeyName = o.KeyName }
+  if o.Subnet != "" { t.Subnet = o.Subnet }
+  if len(o.SecurityGroups) > 0 { t.SecurityGroups = o.SecurityGroups }
+  if o.IamProfile != "" { t.IamProfile = o.IamProfile }
+  if o.Spot { t.Spot = true }
+  t.Tags = mergeStringMaps(t.Tags, o.Tags)
+  t.AgentConfig = mergeStringMaps(t.AgentConfig, o.AgentConfig)
+  return t
+}
+
+func mergeStringMaps(a, b map[string]string) (map[string]string) {
+  m := make(map[string]string)
+  for k, v := range a { m[k] = v }
+  for k, v := range b { m[k] = v }
+  return m
+}
+
+// Resolves a template into a spec for an instance that will join clusterName.
+func (t launchTemplate) launchSpec(clusterName string, sess *session.Session) (*launchSpec, error) {
+  spec := &launchSpec{
+    ImageId: t.Ami,
+    InstanceType: t.InstanceType,
+    KeyName: t.KeyName,
+    SubnetId: t.Subnet,
+    SecurityGroupIds: t.SecurityGroups,
+    Spot: t.Spot,
+  }
+  if spec.InstanceType == "" { spec.InstanceType = defaultInstanceType }
+  if spec.ImageId == "" {
+    ami, err := latestEcsAmi(sess)
+    if err != nil { return nil, err }
+    spec.ImageId = ami
+  }
+
+  profile := t.IamProfile
+  if profile == "" { profile = defaultInstanceProfile }
+  if strings.HasPrefix(profile, "arn:") {
+    spec.IamInstanceProfileArn = profile
+  } else {
+    spec.IamInstanceProfileName = profile
+  }
+
+  tags := mergeStringMaps(map[string]string{"Name": fmt.Sprintf("%s - ecs instance", clusterName)}, t.Tags)
+  keys := make([]string, 0, len(tags))
+  for k := range tags { keys = append(keys, k) }
+  sort.Strings(keys)
+  for _, k := range keys {
+    spec.Tags = append(spec.Tags, &ec2.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+  }
+
+  spec.UserData = base64.StdEncoding.EncodeToString([]byte(agentUserData(clusterName, t.AgentConfig)))
+  return spec, nil
+}
+
+// User data that writes the ECS agent config, joining the instance to the cluster.
+func agentUserData(clusterName string, agentConfig map[string]string) (string) {
+  config := mergeStringMaps(agentConfig, map[string]string{"ECS_CLUSTER": clusterName})
+  keys := make([]string, 0, len(config))
+  for k := range config { keys = append(keys, k) }
+  sort.Strings(keys)
+  ud := "#!/bin/bash\n"
+  for _, k := range keys {
+    ud += fmt.Sprintf("echo '%s=%s' >> /etc/ecs/ecs.config\n", k, config[k])
+  }
+  return ud
+}
+
+// AWS publishes the current ECS optimized AMI in SSM.
+func latestEcsAmi(sess *session.Session) (string, error) {
+  resp, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{Name: aws.String(latestEcsAmiParameter)})
+  if err != nil { return "", fmt.Errorf("Can't look up the latest ECS optimized AMI: %s", err) }
+  return aws.StringValue(resp.Parameter.Value), nil
+}
+
+// What we need to launch an ECS container instance ourselves,
+// rather than take the awslib defaults.
+type launchSpec struct {
+  ImageId string
+  InstanceType string
+  KeyName string
+  SubnetId string
+  SecurityGroupIds []string
+  IamInstanceProfileArn string
+  IamInstanceProfileName string
+  UserData string // base64 encoded, as EC2 wants it.
+  Spot bool
+  Tags []*ec2.Tag
+}
+
+// A spec for a copy of an existing instance, user data (and so the ECS agent config) included.
+func launchSpecFromInstance(inst *ec2.Instance, sess *session.Session) (*launchSpec, error) {
+  spec := &launchSpec{
+    ImageId: aws.StringValue(inst.ImageId),
+    InstanceType: aws.StringValue(inst.InstanceType),
+    KeyName: aws.StringValue(inst.KeyName),
+    SubnetId: aws.StringValue(inst.SubnetId),
+    Tags: inst.Tags,
+    Spot: aws.StringValue(inst.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot,
+  }
+  for _, sg := range inst.SecurityGroups {
+    spec.SecurityGroupIds = append(spec.SecurityGroupIds, *sg.GroupId)
+  }
+  if inst.IamInstanceProfile != nil {
+    spec.IamInstanceProfileArn = aws.StringValue(inst.IamInstanceProfile.Arn)
+  }
+
+  resp, err := ec2.New(sess).DescribeInstanceAttribute(&ec2.DescribeInstanceAttributeInput{
+    InstanceId: inst.InstanceId,
+    Attribute: aws.String(ec2.InstanceAttributeNameUserData),
+  })
+  if err != nil { return nil, fmt.Errorf("Can't get user data for %s: %s", *inst.InstanceId, err) }
+  if resp.UserData != nil { spec.UserData = aws.StringValue(resp.UserData.Value) }
+  return spec, nil
+}
+
+func (spec *launchSpec) runInstancesInput(count int64) (*ec2.RunInstancesInput) {
+  input := &ec2.RunInstancesInput{
+    ImageId: aws.String(spec.ImageId),
+    InstanceType: aws.String(spec.InstanceType),
+    MinCount: aws.Int64(count),
+    MaxCount: aws.Int64(count),
+  }
+  if spec.KeyName != "" { input.KeyName = aws.String(spec.KeyName) }
+  if spec.SubnetId != "" { input.SubnetId = aws.String(spec.SubnetId) }
+  if len(spec.SecurityGroupIds) > 0 { input.SecurityGroupIds = aws.StringSlice(spec.SecurityGroupIds) }
+  if spec.IamInstanceProfileArn != "" {
+    input.IamInstanceProfile = &ec2.IamInstanceProfileSpecification{Arn: aws.String(spec.IamInstanceProfileArn)}
+  } else if spec.IamInstanceProfileName != "" {
+    input.IamInstanceProfile = &ec2.IamInstanceProfileSpecification{Name: aws.String(spec.IamInstanceProfileName)}
+  }
+  if spec.UserData != "" { input.UserData = aws.String(spec.UserData) }
+  if spec.Spot {
+    input.InstanceMarketOptions = &ec2.InstanceMarketOptionsRequest{MarketType: aws.String(ec2.MarketTypeSpot)}
+  }
+
+  // aws: tags are reserved and can't be set on launch.
+  tags := make([]*ec2.Tag, 0)
+  for _, t := range spec.Tags {
+    if !strings.HasPrefix(*t.Key, "aws:") { tags = append(tags, t) }
+  }
+  if len(tags) > 0 {
+    input.TagSpecifications = []*ec2.TagSpecification{
+      {ResourceType: aws.String(ec2.ResourceTypeInstance), Tags: tags},
+    }
+  }
+  return input
+}
+
+func launchInstances(spec *launchSpec, count int64, sess *session.Session) (*ec2.Reservation, error) {
+  return ec2.New(sess).RunInstances(spec.runInstancesInput(count))
+}
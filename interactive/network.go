@@ -0,0 +1,183 @@
+package interactive
+
+import (
+  "fmt"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ec2"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const (
+  eniAttachmentType = "ElasticNetworkInterface"
+  eniIdDetail = "networkInterfaceId"
+  eniPrivateIpDetail = "privateIPv4Address"
+)
+
+// Launch and network settings for run task and create service.
+// With none of them set we use the awslib defaults (EC2, bridge networking).
+type taskNetworkOptions struct {
+  LaunchType string
+  Subnets []string
+  SecurityGroups []string
+  AssignPublicIp bool
+}
+
+func (o taskNetworkOptions) isDefault() (bool) {
+  return (o.LaunchType == "" || o.LaunchType == ecs.LaunchTypeEc2) && len(o.Subnets) == 0 &&
+    len(o.SecurityGroups) == 0 && !o.AssignPublicIp
+}
+
+// Copy with the subnet and security group flags split on commas.
+func (o taskNetworkOptions) withLists() (taskNetworkOptions) {
+  o.Subnets = splitListArg(o.Subnets)
+  o.SecurityGroups = splitListArg(o.SecurityGroups)
+  return o
+}
+
+func (o taskNetworkOptions) networkConfiguration() (*ecs.NetworkConfiguration) {
+  if len(o.Subnets) == 0 { return nil }
+  assign := ecs.AssignPublicIpDisabled
+  if o.AssignPublicIp { assign = ecs.AssignPublicIpEnabled }
+  return &ecs.NetworkConfiguration{
+    AwsvpcConfiguration: &ecs.AwsVpcConfiguration{
+      Subnets: aws.StringSlice(o.Subnets),
+      SecurityGroups: aws.StringSlice(o.SecurityGroups),
+      AssignPublicIp: aws.String(assign),
+    },
+  }
+}
+
+func (o taskNetworkOptions) validate() (error) {
+  if o.LaunchType == ecs.LaunchTypeFargate && len(o.Subnets) == 0 {
+    return fmt.Errorf("Fargate tasks need at least one subnet (--subnets)")
+  }
+  if len(o.Subnets) == 0 && (len(o.SecurityGroups) > 0 || o.AssignPublicIp) {
+    return fmt.Errorf("--security-groups and --assign-public-ip are for awsvpc networking, which needs --subnets")
+  }
+  if o.AssignPublicIp && o.LaunchType != ecs.LaunchTypeFargate {
+    return fmt.Errorf("--assign-public-ip is only for the FARGATE launch type")
+  }
+  return nil
+}
+
+// Flags may be repeated or given as a comma separated list.
+func splitListArg(args []string) (l []string) {
+  for _, a := range args {
+    for _, s := range strings.Split(a, ",") {
+      if s = strings.TrimSpace(s); s != "" { l = append(l, s) }
+    }
+  }
+  return l
+}
+
+func runTaskWithNetwork(clusterName, taskDefinition string, envMap awslib.ContainerEnvironmentMap,
+  opts taskNetworkOptions, sess *session.Session) (*ecs.RunTaskOutput, error) {
+  if err := opts.validate(); err != nil { return nil, err }
+
+  input := &ecs.RunTaskInput{
+    Cluster: aws.String(clusterName),
+    TaskDefinition: aws.String(taskDefinition),
+    Count: aws.Int64(1),
+    NetworkConfiguration: opts.networkConfiguration(),
+  }
+  if opts.LaunchType != "" { input.LaunchType = aws.String(opts.LaunchType) }
+  if len(envMap) > 0 {
+    overrides := make([]*ecs.ContainerOverride, 0)
+    for cName, env := range envMap {
+      co := &ecs.ContainerOverride{Name: aws.String(cName)}
+      for k, v := range env {
+        co.Environment = append(co.Environment, &ecs.KeyValuePair{Name: aws.String(k), Value: aws.String(v)})
+      }
+      overrides = append(overrides, co)
+    }
+    input.Overrides = &ecs.TaskOverride{ContainerOverrides: overrides}
+  }
+  return ecs.New(sess).RunTask(input)
+}
+
+func createServiceWithNetwork(serviceName, clusterName, taskDefinition string, instanceCount int64,
+  opts taskNetworkOptions, sess *session.Session) (*ecs.Service, error) {
+  if err := opts.validate(); err != nil { return nil, err }
+
+  input := &ecs.CreateServiceInput{
+    Cluster: aws.String(clusterName),
+    ServiceName: aws.String(serviceName),
+    TaskDefinition: aws.String(taskDefinition),
+    DesiredCount: aws.Int64(instanceCount),
+    NetworkConfiguration: opts.networkConfiguration(),
+  }
+  if opts.LaunchType != "" { input.LaunchType = aws.String(opts.LaunchType) }
+  resp, err := ecs.New(sess).CreateService(input)
+  if err != nil { return nil, err }
+  return resp.Service, nil
+}
+
+// awsvpc and Fargate tasks get their own ENI, described in the task's attachments.
+func taskENI(t *ecs.Task) (eniId, privateIp string) {
+  for _, a := range t.Attachments {
+    if aws.StringValue(a.Type) != eniAttachmentType { continue }
+    for _, d := range a.Details {
+      switch aws.StringValue(d.Name) {
+      case eniIdDetail: eniId = aws.StringValue(d.Value)
+      case eniPrivateIpDetail: privateIp = aws.StringValue(d.Value)
+      }
+    }
+  }
+  return eniId, privateIp
+}
+
+// Public addresses keyed by ENI id, ENIs without a public address are left out.
+func eniPublicAddresses(eniIds []string, sess *session.Session) (map[string]string, error) {
+  addrs := make(map[string]string)
+  if len(eniIds) == 0 { return addrs, nil }
+  resp, err := ec2.New(sess).DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+    NetworkInterfaceIds: aws.StringSlice(eniIds),
+  })
+  if err != nil { return addrs, err }
+  for _, ni := range resp.NetworkInterfaces {
+    if ni.Association != nil && ni.Association.PublicIp != nil {
+      addrs[*ni.NetworkInterfaceId] = *ni.Association.PublicIp
+    }
+  }
+  return addrs, nil
+}
+
+type taskAddress struct {
+  Public string
+  Private string
+}
+
+// Addresses for each task keyed by task ARN. Tasks with an ENI use its addresses,
+// the rest use their container instance's EC2 host.
+func deepTaskAddresses(dtl []*awslib.DeepTask, sess *session.Session) (map[string]taskAddress) {
+  addrs := make(map[string]taskAddress)
+  eniIds := make([]string, 0)
+  for _, dt := range dtl {
+    if eniId, _ := taskENI(dt.Task); eniId != "" { eniIds = append(eniIds, eniId) }
+  }
+  publicAddrs, err := eniPublicAddresses(eniIds, sess)
+  if err != nil {
+    fmt.Printf("%sCouldn't get task network interfaces: %s%s\n", warnColor, err, resetColor)
+  }
+
+  for _, dt := range dtl {
+    eniId, private := taskENI(dt.Task)
+    if eniId == "" {
+      addrs[*dt.Task.TaskArn] = taskAddress{Public: dt.PublicIpAddress(), Private: dt.PrivateIpAddress()}
+      continue
+    }
+    public, ok := publicAddrs[eniId]
+    if !ok { public = "<none>" }
+    addrs[*dt.Task.TaskArn] = taskAddress{Public: public, Private: private}
+  }
+  return addrs
+}
+
+func deepTaskAddress(dt *awslib.DeepTask, sess *session.Session) (taskAddress) {
+  return deepTaskAddresses([]*awslib.DeepTask{dt}, sess)[*dt.Task.TaskArn]
+}
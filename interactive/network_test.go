@@ -0,0 +1,25 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestTaskNetworkOptions(t *testing.T) {
+  assert.True(t, taskNetworkOptions{}.isDefault())
+  assert.True(t, taskNetworkOptions{LaunchType: ecs.LaunchTypeEc2}.isDefault())
+
+  for _, o := range []taskNetworkOptions{
+    {SecurityGroups: []string{"sg-1"}},
+    {AssignPublicIp: true},
+    {LaunchType: ecs.LaunchTypeEc2, Subnets: []string{"subnet-1"}, AssignPublicIp: true},
+    {LaunchType: ecs.LaunchTypeFargate},
+  } {
+    assert.False(t, o.isDefault(), "%#v", o)
+    assert.Error(t, o.validate(), "%#v", o)
+  }
+
+  assert.NoError(t, taskNetworkOptions{Subnets: []string{"subnet-1"}, SecurityGroups: []string{"sg-1"}}.validate())
+  assert.NoError(t, taskNetworkOptions{LaunchType: ecs.LaunchTypeFargate, Subnets: []string{"subnet-1"}, AssignPublicIp: true}.validate())
+}
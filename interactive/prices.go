@@ -0,0 +1,126 @@
+package interactive
+
+import (
+  "fmt"
+  "io/ioutil"
+  "os"
+  "gopkg.in/yaml.v2"
+)
+
+const pricesFileName = "prices.yaml"
+
+// Linux hourly prices in USD by region and instance type. This only covers the usual
+// ECS instance types and will drift, a prices.yaml in the config dir in the same
+// format is merged over it.
+const bundledPrices = `
+us-east-1:
+  t2.micro: {onDemand: 0.0116, spot: 0.0035}
+  t2.small: {onDemand: 0.023, spot: 0.0069}
+  t2.medium: {onDemand: 0.0464, spot: 0.0139}
+  t2.large: {onDemand: 0.0928, spot: 0.0278}
+  t2.xlarge: {onDemand: 0.1856, spot: 0.0557}
+  t3.micro: {onDemand: 0.0104, spot: 0.0031}
+  t3.small: {onDemand: 0.0208, spot: 0.0062}
+  t3.medium: {onDemand: 0.0416, spot: 0.0125}
+  t3.large: {onDemand: 0.0832, spot: 0.025}
+  t3.xlarge: {onDemand: 0.1664, spot: 0.0499}
+  m5.large: {onDemand: 0.096, spot: 0.0346}
+  m5.xlarge: {onDemand: 0.192, spot: 0.0691}
+  m5.2xlarge: {onDemand: 0.384, spot: 0.1382}
+  m5.4xlarge: {onDemand: 0.768, spot: 0.2765}
+  c5.large: {onDemand: 0.085, spot: 0.0306}
+  c5.xlarge: {onDemand: 0.17, spot: 0.0612}
+  c5.2xlarge: {onDemand: 0.34, spot: 0.1224}
+  r5.large: {onDemand: 0.126, spot: 0.0441}
+  r5.xlarge: {onDemand: 0.252, spot: 0.0882}
+  r5.2xlarge: {onDemand: 0.504, spot: 0.1764}
+us-west-2:
+  t2.micro: {onDemand: 0.0116, spot: 0.0035}
+  t2.small: {onDemand: 0.023, spot: 0.0069}
+  t2.medium: {onDemand: 0.0464, spot: 0.0139}
+  t2.large: {onDemand: 0.0928, spot: 0.0278}
+  t2.xlarge: {onDemand: 0.1856, spot: 0.0557}
+  t3.micro: {onDemand: 0.0104, spot: 0.0031}
+  t3.small: {onDemand: 0.0208, spot: 0.0062}
+  t3.medium: {onDemand: 0.0416, spot: 0.0125}
+  t3.large: {onDemand: 0.0832, spot: 0.025}
+  t3.xlarge: {onDemand: 0.1664, spot: 0.0499}
+  m5.large: {onDemand: 0.096, spot: 0.0346}
+  m5.xlarge: {onDemand: 0.192, spot: 0.0691}
+  m5.2xlarge: {onDemand: 0.384, spot: 0.1382}
+  m5.4xlarge: {onDemand: 0.768, spot: 0.2765}
+  c5.large: {onDemand: 0.085, spot: 0.0306}
+  c5.xlarge: {onDemand: 0.17, spot: 0.0612}
+  c5.2xlarge: {onDemand: 0.34, spot: 0.1224}
+  r5.large: {onDemand: 0.126, spot: 0.0441}
+  r5.xlarge: {onDemand: 0.252, spot: 0.0882}
+  r5.2xlarge: {onDemand: 0.504, spot: 0.1764}
+eu-west-1:
+  t2.micro: {onDemand: 0.0126, spot: 0.0038}
+  t2.small: {onDemand: 0.025, spot: 0.0075}
+  t2.medium: {onDemand: 0.05, spot: 0.015}
+  t2.large: {onDemand: 0.1008, spot: 0.0302}
+  t2.xlarge: {onDemand: 0.2016, spot: 0.0605}
+  t3.micro: {onDemand: 0.0114, spot: 0.0034}
+  t3.small: {onDemand: 0.0228, spot: 0.0068}
+  t3.medium: {onDemand: 0.0456, spot: 0.0137}
+  t3.large: {onDemand: 0.0912, spot: 0.0274}
+  t3.xlarge: {onDemand: 0.1824, spot: 0.0547}
+  m5.large: {onDemand: 0.107, spot: 0.0385}
+  m5.xlarge: {onDemand: 0.214, spot: 0.077}
+  m5.2xlarge: {onDemand: 0.428, spot: 0.1541}
+  m5.4xlarge: {onDemand: 0.856, spot: 0.3082}
+  c5.large: {onDemand: 0.096, spot: 0.0346}
+  c5.xlarge: {onDemand: 0.192, spot: 0.0691}
+  c5.2xlarge: {onDemand: 0.384, spot: 0.1382}
+  r5.large: {onDemand: 0.141, spot: 0.0494}
+  r5.xlarge: {onDemand: 0.282, spot: 0.0987}
+  r5.2xlarge: {onDemand: 0.564, spot: 0.1974}
+`
+
+type instancePrice struct {
+  OnDemand float64 `yaml:"onDemand"`
+  Spot float64 `yaml:"spot"`
+}
+
+// region -> instance type -> price
+type priceTable map[string]map[string]instancePrice
+
+// Merges o over pt a price at a time, an entry that only gives onDemand keeps the spot price.
+func (pt priceTable) merge(o priceTable) {
+  for region, types := range o {
+    if pt[region] == nil { pt[region] = make(map[string]instancePrice) }
+    for instanceType, price := range types {
+      p := pt[region][instanceType]
+      if price.OnDemand > 0 { p.OnDemand = price.OnDemand }
+      if price.Spot > 0 { p.Spot = price.Spot }
+      pt[region][instanceType] = p
+    }
+  }
+}
+
+// Hourly price, false if we don't have one.
+func (pt priceTable) price(region, instanceType string, spot bool) (float64, bool) {
+  p, ok := pt[region][instanceType]
+  if !ok { return 0, false }
+  if spot {
+    return p.Spot, p.Spot > 0
+  }
+  return p.OnDemand, p.OnDemand > 0
+}
+
+// The bundled prices with the user's prices.yaml on top.
+func loadPriceTable() (priceTable, error) {
+  prices := make(priceTable)
+  if err := yaml.Unmarshal([]byte(bundledPrices), &prices); err != nil { return nil, err }
+
+  fn, err := configFilePath(pricesFileName)
+  if err != nil { return nil, err }
+  b, err := ioutil.ReadFile(fn)
+  if os.IsNotExist(err) { return prices, nil }
+  if err != nil { return nil, err }
+  updates := make(priceTable)
+  if err = yaml.Unmarshal(b, &updates); err != nil { return nil, fmt.Errorf("Can't read prices %s: %s", fn, err) }
+  prices.merge(updates)
+  return prices, nil
+}
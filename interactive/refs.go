@@ -0,0 +1,227 @@
+package interactive
+
+import (
+  "fmt"
+  "sort"
+  "strconv"
+  "strings"
+  "github.com/alecthomas/kingpin"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Kinds of things list commands number, so later commands can refer to them as #3 (or @3).
+type refKind string
+
+const (
+  taskRefs refKind = "task"
+  instanceRefs refKind = "container instance"
+  serviceRefs refKind = "service"
+  taskDefinitionRefs refKind = "task definition"
+)
+
+// How many matches an ambiguity error lists.
+const ambiguousShown = 5
+
+var refListCommands = map[refKind]string{
+  taskRefs: "task list",
+  instanceRefs: "instance list",
+  serviceRefs: "service list",
+  taskDefinitionRefs: "task-definition list",
+}
+
+// The results of the last list of each kind, in the order they were shown.
+type numberedResults struct {
+  Cluster string
+  Values []string
+}
+
+var lastResults = make(map[refKind]numberedResults)
+
+// Called by list commands with what they're about to show, in order, so the rows can be numbered.
+func recordResults(kind refKind, clusterName string, values []string) {
+  lastResults[kind] = numberedResults{Cluster: clusterName, Values: values}
+}
+
+// The row number list commands print for the i'th (from 0) result.
+func refNumberString(i int) (string) {
+  return fmt.Sprintf("#%d", i+1)
+}
+
+// Args that take a reference, by the full command they belong to.
+type refArg struct {
+  kind refKind
+  target *string
+}
+
+var refArgs = make(map[string][]refArg)
+
+// Resolve the value of target as a reference of kind before running cmd.
+func resolvesRef(cmd *kingpin.CmdClause, kind refKind, target *string) {
+  refArgs[cmd.FullCommand()] = append(refArgs[cmd.FullCommand()], refArg{kind: kind, target: target})
+}
+
+// Replaces the reference args of command with what they refer to.
+func resolveRefs(command, clusterName string, sess *session.Session) (error) {
+  for _, ra := range refArgs[command] {
+    v, err := resolveRef(ra.kind, *ra.target, clusterName, sess)
+    if err != nil { return err }
+    *ra.target = v
+  }
+  return nil
+}
+
+// A reference is a row number from the last list (#3 or @3), an ARN, or any unique prefix
+// of an ARN, its resource id, or a name. A task definition family without a revision
+// is its latest ACTIVE revision.
+func resolveRef(kind refKind, ref, clusterName string, sess *session.Session) (string, error) {
+  if n, ok := refNumber(ref); ok { return numberedResult(kind, n, clusterName) }
+  if strings.HasPrefix(ref, "arn:") { return ref, nil }
+  if kind == taskDefinitionRefs { return resolveTaskDefinition(ref, sess) }
+
+  candidates, err := refCandidates(kind, clusterName, sess)
+  if err != nil { return "", fmt.Errorf("Can't look up %s %s: %s", kind, ref, err) }
+  return matchRef(kind, ref, clusterName, candidates)
+}
+
+// #3 or @3.
+func refNumber(ref string) (int, bool) {
+  if len(ref) < 2 || (ref[0] != '#' && ref[0] != '@') { return 0, false }
+  n, err := strconv.Atoi(ref[1:])
+  if err != nil { return 0, false }
+  return n, true
+}
+
+func numberedResult(kind refKind, n int, clusterName string) (string, error) {
+  results, ok := lastResults[kind]
+  if !ok {
+    return "", fmt.Errorf("There's no %s list to take #%d from, use %s first", kind, n, refListCommands[kind])
+  }
+  if kind != taskDefinitionRefs && results.Cluster != clusterName {
+    return "", fmt.Errorf("The last %s list was for cluster %s, not %s, use %s again", kind, results.Cluster,
+      clusterName, refListCommands[kind])
+  }
+  if n < 1 || n > len(results.Values) {
+    return "", fmt.Errorf("There's no #%d, the last %s list had %d", n, refListCommands[kind], len(results.Values))
+  }
+  return results.Values[n-1], nil
+}
+
+// The ways a candidate can be referred to: the whole thing and,
+// for ARNs, each trailing part of the resource, eg. task/cluster/id, cluster/id and id.
+func refNames(candidate string) ([]string) {
+  names := []string{candidate}
+  if !strings.HasPrefix(candidate, "arn:") { return names }
+  resource := candidate[strings.LastIndex(candidate, ":")+1:]
+  parts := strings.Split(resource, "/")
+  for i := range parts {
+    names = append(names, strings.Join(parts[i:], "/"))
+  }
+  return names
+}
+
+// An exact match on any name wins, otherwise the candidate must be the only one with a name starting with ref.
+func matchRef(kind refKind, ref, clusterName string, candidates []string) (string, error) {
+  matches := make([]string, 0)
+  for _, c := range candidates {
+    prefixed := false
+    for _, name := range refNames(c) {
+      if name == ref { return c, nil }
+      if strings.HasPrefix(name, ref) { prefixed = true }
+    }
+    if prefixed { matches = append(matches, c) }
+  }
+
+  switch len(matches) {
+  case 0:
+    return "", fmt.Errorf("No %s on cluster %s matches %s", kind, clusterName, ref)
+  case 1:
+    return matches[0], nil
+  }
+  sort.Strings(matches)
+  shown := make([]string, 0, ambiguousShown)
+  for i, m := range matches {
+    if i == ambiguousShown {
+      shown = append(shown, fmt.Sprintf("and %d more", len(matches) - ambiguousShown))
+      break
+    }
+    names := refNames(m)
+    shown = append(shown, names[len(names)-1])
+  }
+  return "", fmt.Errorf("%s matches %d %ss on cluster %s, give more of it: %s", ref, len(matches), kind,
+    clusterName, strings.Join(shown, ", "))
+}
+
+// Task and container instance ARNs, or service names.
+func refCandidates(kind refKind, clusterName string, sess *session.Session) ([]string, error) {
+  ecsSvc := ecs.New(sess)
+  values := make([]string, 0)
+  var err error
+  switch kind {
+  case taskRefs:
+    // Stopped tasks too, so task why can find them.
+    for _, status := range []string{ecs.DesiredStatusRunning, ecs.DesiredStatusStopped} {
+      err = ecsSvc.ListTasksPages(&ecs.ListTasksInput{Cluster: aws.String(clusterName), DesiredStatus: aws.String(status)},
+        func(page *ecs.ListTasksOutput, last bool) bool {
+          values = append(values, aws.StringValueSlice(page.TaskArns)...)
+          return true
+        })
+      if err != nil { return nil, err }
+    }
+  case instanceRefs:
+    err = ecsSvc.ListContainerInstancesPages(&ecs.ListContainerInstancesInput{Cluster: aws.String(clusterName)},
+      func(page *ecs.ListContainerInstancesOutput, last bool) bool {
+        values = append(values, aws.StringValueSlice(page.ContainerInstanceArns)...)
+        return true
+      })
+  case serviceRefs:
+    err = ecsSvc.ListServicesPages(&ecs.ListServicesInput{Cluster: aws.String(clusterName)},
+      func(page *ecs.ListServicesOutput, last bool) bool {
+        for _, arn := range aws.StringValueSlice(page.ServiceArns) {
+          values = append(values, arn[strings.LastIndex(arn, "/")+1:])
+        }
+        return true
+      })
+  }
+  return values, err
+}
+
+// family:revision is taken as is, a family or unique family prefix is its latest ACTIVE revision.
+func resolveTaskDefinition(ref string, sess *session.Session) (string, error) {
+  if strings.Contains(ref, ":") { return ref, nil }
+  ecsSvc := ecs.New(sess)
+  families := make([]string, 0)
+  err := ecsSvc.ListTaskDefinitionFamiliesPages(&ecs.ListTaskDefinitionFamiliesInput{
+    FamilyPrefix: aws.String(ref),
+    Status: aws.String(ecs.TaskDefinitionFamilyStatusActive),
+  }, func(page *ecs.ListTaskDefinitionFamiliesOutput, last bool) bool {
+    families = append(families, aws.StringValueSlice(page.Families)...)
+    return true
+  })
+  if err != nil { return "", fmt.Errorf("Can't look up task definition %s: %s", ref, err) }
+
+  family := ""
+  for _, f := range families {
+    if f == ref { family = f }
+  }
+  if family == "" {
+    switch len(families) {
+    case 0:
+      return "", fmt.Errorf("No active task definition family matches %s", ref)
+    case 1:
+      family = families[0]
+    default:
+      sort.Strings(families)
+      if len(families) > ambiguousShown {
+        families = append(families[:ambiguousShown], fmt.Sprintf("and %d more", len(families) - ambiguousShown))
+      }
+      return "", fmt.Errorf("%s matches several task definition families, give more of it: %s", ref, strings.Join(families, ", "))
+    }
+  }
+
+  // Describing a family without a revision gets the latest ACTIVE one.
+  resp, err := ecsSvc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String(family)})
+  if err != nil { return "", fmt.Errorf("Can't get the latest revision of %s: %s", family, err) }
+  return aws.StringValue(resp.TaskDefinition.TaskDefinitionArn), nil
+}
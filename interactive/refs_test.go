@@ -0,0 +1,60 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestRefNumber(t *testing.T) {
+  n, ok := refNumber("#3")
+  assert.True(t, ok)
+  assert.Equal(t, 3, n)
+  n, ok = refNumber("@12")
+  assert.True(t, ok)
+  assert.Equal(t, 12, n)
+  for _, ref := range []string{"#", "3", "#x", "web", "arn:aws:ecs:us-east-1:123:task/c/abc"} {
+    _, ok = refNumber(ref)
+    assert.False(t, ok, ref)
+  }
+}
+
+func TestNumberedResult(t *testing.T) {
+  delete(lastResults, instanceRefs)
+  _, err := numberedResult(instanceRefs, 1, "prod")
+  assert.Error(t, err, "Should need a list first.")
+
+  recordResults(instanceRefs, "prod", []string{"one", "two"})
+  v, err := numberedResult(instanceRefs, 2, "prod")
+  assert.NoError(t, err)
+  assert.Equal(t, "two", v)
+  _, err = numberedResult(instanceRefs, 3, "prod")
+  assert.Error(t, err, "Should be out of range.")
+  _, err = numberedResult(instanceRefs, 1, "test")
+  assert.Error(t, err, "Should be for another cluster.")
+}
+
+func TestMatchRef(t *testing.T) {
+  arns := []string{
+    "arn:aws:ecs:us-east-1:123456789012:task/prod/0a1b2c",
+    "arn:aws:ecs:us-east-1:123456789012:task/prod/0a9f00",
+    "arn:aws:ecs:us-east-1:123456789012:task/prod/7e7e7e",
+  }
+  v, err := matchRef(taskRefs, "7e", "prod", arns)
+  assert.NoError(t, err)
+  assert.Equal(t, arns[2], v)
+  v, err = matchRef(taskRefs, "task/prod/0a1", "prod", arns)
+  assert.NoError(t, err)
+  assert.Equal(t, arns[0], v)
+
+  _, err = matchRef(taskRefs, "0a", "prod", arns)
+  if assert.Error(t, err) {
+    assert.Contains(t, err.Error(), "0a1b2c")
+    assert.Contains(t, err.Error(), "0a9f00")
+  }
+  _, err = matchRef(taskRefs, "ff", "prod", arns)
+  assert.Error(t, err)
+
+  v, err = matchRef(serviceRefs, "web", "prod", []string{"web", "web-worker"})
+  assert.NoError(t, err, "Exact names should win over prefixes.")
+  assert.Equal(t, "web", v)
+}
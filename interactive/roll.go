@@ -0,0 +1,257 @@
+package interactive
+
+import (
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "sort"
+  "sync"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+// Saved after every step so an interrupted roll can pick up where it left off.
+type rollState struct {
+  Cluster string `json:"cluster"`
+  Ami string `json:"ami"`
+  Batch int `json:"batch"`
+  Started time.Time `json:"started"`
+  Remaining []string `json:"remaining"`
+  Launched map[string]string `json:"launched"`
+  Replaced []rolledInstance `json:"replaced"`
+}
+
+type rolledInstance struct {
+  OldArn string `json:"oldArn"`
+  OldEc2Id string `json:"oldEc2Id"`
+  NewArn string `json:"newArn"`
+  NewEc2Id string `json:"newEc2Id"`
+  Finished time.Time `json:"finished"`
+}
+
+func rollStatePath(clusterName string) (string, error) {
+  return configFilePath(fmt.Sprintf("roll-%s.json", clusterName))
+}
+
+func loadRollState(clusterName string) (*rollState, error) {
+  fn, err := rollStatePath(clusterName)
+  if err != nil { return nil, err }
+  b, err := ioutil.ReadFile(fn)
+  if os.IsNotExist(err) { return nil, nil }
+  if err != nil { return nil, err }
+  state := new(rollState)
+  if err = json.Unmarshal(b, state); err != nil { return nil, fmt.Errorf("Can't read roll state %s: %s", fn, err) }
+  return state, nil
+}
+
+func (state *rollState) save() (error) {
+  fn, err := rollStatePath(state.Cluster)
+  if err != nil { return err }
+  b, err := json.MarshalIndent(state, "", "  ")
+  if err != nil { return err }
+  return ioutil.WriteFile(fn, b, 0600)
+}
+
+func (state *rollState) remove() (error) {
+  fn, err := rollStatePath(state.Cluster)
+  if err != nil { return err }
+  return os.Remove(fn)
+}
+
+// Replace every container instance in the cluster, batch at a time:
+// launch replacements, wait for them to be ACTIVE, drain the old ones,
+// wait for the services to be stable again and then terminate the old ones.
+func doRollInstances(clusterName string, batch int, ami string, restart, force bool, sess *session.Session) (error) {
+  if batch < 1 { return fmt.Errorf("Batch size must be at least 1") }
+
+  state, err := loadRollState(clusterName)
+  if err != nil { return err }
+  if state != nil && restart {
+    fmt.Printf("%sDiscarding the roll of %s started %s.%s\n", warnColor, clusterName, state.Started.Local().Format(humanTimeFormat), resetColor)
+    state = nil
+  }
+  if state == nil {
+    ciMap, _, err := awslib.GetContainerMaps(clusterName, sess)
+    if err != nil { return err }
+    state = &rollState{Cluster: clusterName, Ami: ami, Batch: batch, Started: time.Now(), Launched: make(map[string]string)}
+    for arn, ci := range ciMap {
+      if ci.Instance != nil && *ci.Instance.Status == ecs.ContainerInstanceStatusActive {
+        state.Remaining = append(state.Remaining, arn)
+      }
+    }
+    sort.Strings(state.Remaining)
+    if len(state.Remaining) == 0 {
+      fmt.Printf("%sThere are no active instances on %s to replace.%s\n", warnColor, clusterName, resetColor)
+      return nil
+    }
+  } else {
+    fmt.Printf("%sResuming the roll of %s started %s: %d replaced, %d to go.%s\n", infoColor, clusterName,
+      state.Started.Local().Format(humanTimeFormat), len(state.Replaced), len(state.Remaining), resetColor)
+    if ami != "" { state.Ami = ami }
+    state.Batch = batch
+  }
+
+  ok, err := confirmDestructive(fmt.Sprintf("Drain and replace %d instances on cluster %s", len(state.Remaining), clusterName),
+    clusterName, "", force)
+  if err != nil || !ok { return err }
+  if err = state.save(); err != nil { return err }
+
+  for len(state.Remaining) > 0 {
+    n := state.Batch
+    if n > len(state.Remaining) { n = len(state.Remaining) }
+    if err = rollBatch(state, state.Remaining[:n], sess); err != nil {
+      fmt.Printf("%sRoll stopped, run it again to resume.%s\n", failColor, resetColor)
+      return err
+    }
+    printRollProgress(state)
+  }
+
+  fmt.Printf("%sAll instances on %s replaced (%s).%s\n", successColor, clusterName,
+    shortDurationString(time.Since(state.Started)), resetColor)
+  return state.remove()
+}
+
+func rollBatch(state *rollState, oldArns []string, sess *session.Session) (error) {
+  clusterName := state.Cluster
+  ciMap, ec2Map, err := awslib.GetContainerMaps(clusterName, sess)
+  if err != nil { return err }
+
+  replaced := make([]rolledInstance, 0, len(oldArns))
+  for _, oldArn := range oldArns {
+    ci, ok := ciMap[oldArn]
+    if !ok || ci.Instance == nil {
+      fmt.Printf("%sContainer instance %s is already gone, skipping it.%s\n", warnColor, awslib.ShortArnString(&oldArn), resetColor)
+      // A replacement may have been launched before an interruption, still wait for it.
+      replaced = append(replaced, rolledInstance{OldArn: oldArn, NewEc2Id: state.Launched[oldArn]})
+      continue
+    }
+    r := rolledInstance{OldArn: oldArn, OldEc2Id: *ci.Instance.Ec2InstanceId}
+
+    // Only launch if we didn't get to it before being interrupted.
+    r.NewEc2Id = state.Launched[oldArn]
+    if r.NewEc2Id == "" {
+      spec, err := launchSpecFromInstance(ec2Map[r.OldEc2Id], sess)
+      if err != nil { return err }
+      if state.Ami != "" { spec.ImageId = state.Ami }
+      resp, err := launchInstances(spec, 1, sess)
+      if err != nil { return fmt.Errorf("Failed to launch a replacement for %s: %s", r.OldEc2Id, err) }
+      r.NewEc2Id = *resp.Instances[0].InstanceId
+      state.Launched[oldArn] = r.NewEc2Id
+      if err = state.save(); err != nil { return err }
+      fmt.Printf("%s%sLaunched %s (%s) to replace %s.%s\n", infoColor, nowString(), r.NewEc2Id, spec.ImageId, r.OldEc2Id, resetColor)
+    }
+    replaced = append(replaced, r)
+  }
+
+  // Wait for the replacements to join the cluster.
+  for i := range replaced {
+    r := &replaced[i]
+    if r.NewEc2Id == "" { continue }
+    arn, err := waitForContainerInstanceActive(clusterName, r.NewEc2Id, sess)
+    if err != nil { return fmt.Errorf("Replacement %s didn't become active: %s", r.NewEc2Id, err) }
+    r.NewArn = arn
+    fmt.Printf("%s%sReplacement %s is ACTIVE.%s\n", successColor, nowString(), r.NewEc2Id, resetColor)
+  }
+
+  // Drain the old instances, then let the services settle.
+  for _, r := range replaced {
+    if r.OldEc2Id == "" { continue }
+    if err = waitForDrain(clusterName, r.OldArn, sess); err != nil { return err }
+  }
+  if err = waitForServicesStable(clusterName, sess); err != nil { return err }
+
+  for _, r := range replaced {
+    if r.OldEc2Id != "" {
+      if err = terminateContainerInstance(clusterName, r.OldArn, sess); err != nil { return err }
+    }
+    r.Finished = time.Now()
+    state.Replaced = append(state.Replaced, r)
+    delete(state.Launched, r.OldArn)
+    state.Remaining = removeString(state.Remaining, r.OldArn)
+    if err = state.save(); err != nil { return err }
+  }
+  return nil
+}
+
+func waitForContainerInstanceActive(clusterName, ec2Id string, sess *session.Session) (string, error) {
+  type result struct {
+    arn string
+    err error
+  }
+  done := make(chan result, 1)
+  awslib.OnContainerInstanceActive(clusterName, ec2Id, sess, func(ci *ecs.ContainerInstance, err error) {
+    if err != nil {
+      done <- result{err: err}
+      return
+    }
+    done <- result{arn: *ci.ContainerInstanceArn}
+  })
+  r := <-done
+  return r.arn, r.err
+}
+
+func waitForDrain(clusterName, ciArn string, sess *session.Session) (error) {
+  done := make(chan error, 1)
+  if err := drainContainerInstance(clusterName, ciArn, sess, func(err error) { done <- err }); err != nil {
+    return err
+  }
+  return <-done
+}
+
+func waitForServicesStable(clusterName string, sess *session.Session) (error) {
+  services, failures, err := awslib.DescribeServices(clusterName, sess)
+  if len(failures) > 0 { printFailures(failures) }
+  if err != nil { return err }
+
+  var wg sync.WaitGroup
+  var mu sync.Mutex
+  var firstErr error
+  for _, s := range services {
+    if *s.Status != "ACTIVE" { continue }
+    serviceName := *s.ServiceName
+    wg.Add(1)
+    awslib.OnServiceStable(serviceName, clusterName, sess, func(err error) {
+      defer wg.Done()
+      if err != nil {
+        mu.Lock()
+        if firstErr == nil { firstErr = fmt.Errorf("Service %s didn't become stable: %s", serviceName, err) }
+        mu.Unlock()
+      }
+    })
+  }
+  wg.Wait()
+  if firstErr == nil {
+    fmt.Printf("%s%sServices on %s are stable.%s\n", successColor, nowString(), clusterName, resetColor)
+  }
+  return firstErr
+}
+
+func printRollProgress(state *rollState) {
+  total := len(state.Replaced) + len(state.Remaining)
+  fmt.Printf("\n%sReplaced %d of %d instances on %s (%s):%s\n", titleColor, len(state.Replaced), total,
+    state.Cluster, shortDurationString(time.Since(state.Started)), resetColor)
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sOld EC2\tNew EC2\tNew ARN\tFinished%s\n", titleColor, resetColor)
+  for _, r := range state.Replaced {
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s%s\n", nullColor, r.OldEc2Id, r.NewEc2Id, awslib.ShortArnString(&r.NewArn),
+      r.Finished.Local().Format(humanTimeFormat), resetColor)
+  }
+  for _, arn := range state.Remaining {
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s%s\n", warnColor, awslib.ShortArnString(&arn), "-", "-", "pending", resetColor)
+  }
+  w.Flush()
+}
+
+func removeString(l []string, s string) ([]string) {
+  out := make([]string, 0, len(l))
+  for _, e := range l {
+    if e != s { out = append(out, e) }
+  }
+  return out
+}
@@ -0,0 +1,66 @@
+package interactive
+
+import (
+  "fmt"
+  "path"
+  "strings"
+)
+
+// Set by --yes, or for a whole script by ecs-pilot run --yes.
+var (
+  yesArg bool
+  assumeYes bool
+)
+
+// Protected clusters are those matching a protectedClusters pattern
+// in the config file, eg. prod or prod-*.
+func isProtected(clusterName string) (bool) {
+  config, err := getConfig()
+  if err != nil { return false }
+  return matchesAny(config.ProtectedClusters, clusterName)
+}
+
+func matchesAny(patterns []string, name string) (bool) {
+  for _, pattern := range patterns {
+    if ok, _ := path.Match(pattern, name); ok { return true }
+  }
+  return false
+}
+
+func printProtectedBanner(clusterName string) {
+  if !isProtected(clusterName) { return }
+  message := fmt.Sprintf("  %s is a PROTECTED cluster, destructive commands need --force  ", clusterName)
+  bar := strings.Repeat("!", len(message) + 4)
+  fmt.Printf("%s%s\n!!%s!!\n%s%s\n", failColor, bar, message, bar, resetColor)
+}
+
+// An error if the cluster is protected and there's no --force.
+func checkProtected(action, clusterName string, force bool) (error) {
+  if isProtected(clusterName) && !force {
+    return fmt.Errorf("Cluster %s is protected, use --force to %s", clusterName, strings.ToLower(action[:1]) + action[1:])
+  }
+  return nil
+}
+
+// Asks before something destructive is done to the cluster. Protected clusters need --force.
+// If typed isn't empty it has to be typed back, on a protected cluster the cluster name
+// has to be typed back if there's nothing else. --yes and --dry-run don't ask. False, with
+// no error, if the answer was no.
+func confirmDestructive(action, clusterName, typed string, force bool) (bool, error) {
+  if err := checkProtected(action, clusterName, force); err != nil { return false, err }
+  if yesArg || assumeYes || dryRun() { return true, nil }
+
+  if isProtected(clusterName) {
+    printProtectedBanner(clusterName)
+    if typed == "" { typed = clusterName }
+  }
+  ok := false
+  if typed != "" {
+    ok = confirmTyped(action, typed)
+  } else {
+    ok = confirm(action + "?")
+  }
+  declined = !ok
+  if !ok { fmt.Printf("%sNothing done.%s\n", warnColor, resetColor) }
+  return ok, nil
+}
@@ -0,0 +1,15 @@
+package interactive
+
+import(
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestProtectedPatterns(t *testing.T) {
+  patterns := []string{"prod", "prod-*"}
+  assert.True(t, matchesAny(patterns, "prod"))
+  assert.True(t, matchesAny(patterns, "prod-east"))
+  assert.False(t, matchesAny(patterns, "production"))
+  assert.False(t, matchesAny(patterns, "staging"))
+  assert.False(t, matchesAny(nil, "prod"))
+}
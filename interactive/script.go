@@ -0,0 +1,131 @@
+package interactive
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "os"
+  "regexp"
+  "strings"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/ecs"
+  "github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Scripts can source scripts, but not forever.
+const maxScriptDepth = 10
+
+var (
+  scriptDepth = 0
+  scriptVarPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+  scriptVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// A script is a file of interactive commands, one to a line, run in order. Besides the commands:
+//
+//   # A comment, to the end of the line, when it's the first thing on the line.
+//   set -e              Stop at the first command that fails, set +e to carry on (the default).
+//   set name=value      Set $name (or ${name}) for the rest of the script, $$ is a $.
+//
+// $cluster is the current cluster unless set, other unset names come from the environment.
+type script struct {
+  name string
+  vars map[string]string
+  stopOnError bool
+}
+
+func newScript(name string, vars map[string]string) (*script) {
+  s := &script{name: name, vars: make(map[string]string)}
+  for k, v := range vars { s.vars[k] = v }
+  return s
+}
+
+// Replaces $name and ${name} with their values.
+func (s *script) expand(line string) (string, error) {
+  var err error
+  expanded := scriptVarPattern.ReplaceAllStringFunc(line, func(m string) (string) {
+    if m == "$$" { return "$" }
+    name := strings.Trim(m, "${}")
+    if v, ok := s.vars[name]; ok { return v }
+    if name == "cluster" { return currentCluster }
+    if v, ok := os.LookupEnv(name); ok { return v }
+    if err == nil { err = fmt.Errorf("$%s isn't set", name) }
+    return m
+  })
+  return expanded, err
+}
+
+// Handles the script's own set lines, returns false for anything else.
+func (s *script) directive(line string) (bool, error) {
+  fields := strings.Fields(line)
+  if len(fields) < 2 || fields[0] != "set" { return false, nil }
+  setting := strings.TrimSpace(strings.TrimPrefix(line, "set"))
+  switch {
+  case setting == "-e":
+    s.stopOnError = true
+  case setting == "+e":
+    s.stopOnError = false
+  case strings.Contains(setting, "="):
+    kv := strings.SplitN(setting, "=", 2)
+    name := strings.TrimSpace(kv[0])
+    if !scriptVarName.MatchString(name) { return true, fmt.Errorf("Bad variable name \"%s\"", name) }
+    s.vars[name] = strings.TrimSpace(kv[1])
+  default:
+    return true, fmt.Errorf("Unknown setting \"%s\", use set -e, set +e or set name=value", setting)
+  }
+  return true, nil
+}
+
+// Runs the lines from r through process. A quit or exit ends the script quietly.
+func (s *script) run(r io.Reader, process func(string) (error)) (error) {
+  scanner := bufio.NewScanner(r)
+  for n := 1; scanner.Scan(); n++ {
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") { continue }
+
+    line, err := s.expand(line)
+    if err == nil {
+      var handled bool
+      handled, err = s.directive(line)
+      if !handled {
+        fmt.Printf("%s%s:%d>%s %s\n", titleEmph, s.name, n, resetColor, line)
+        err = process(line)
+      }
+    }
+    if err == io.EOF { return nil }
+    if err != nil {
+      if s.stopOnError { return fmt.Errorf("%s stopped at line %d: %s", s.name, n, err) }
+      if err != errBadCommand { fmt.Printf("%s%s:%d: %s%s\n", failColor, s.name, n, err, resetColor) }
+    }
+  }
+  return scanner.Err()
+}
+
+func runScriptFile(fileName string, vars map[string]string, process func(string) (error)) (error) {
+  if scriptDepth >= maxScriptDepth { return fmt.Errorf("Scripts are sourcing scripts %d deep, giving up at %s", scriptDepth, fileName) }
+  f, err := os.Open(fileName)
+  if err != nil { return err }
+  defer f.Close()
+
+  scriptDepth++
+  defer func() { scriptDepth-- }()
+  return newScript(fileName, vars).run(f, process)
+}
+
+// Runs a script of interactive commands, as ecs-pilot run does, and waits for
+// anything it left pending before returning. With yes nothing asks for confirmation,
+// as if every command had --yes, and dryRun is as if every command had --dry-run.
+func RunScript(profile, fileName string, vars map[string]string, yes, dry bool, sess *session.Session, defaultConfig *aws.Config) (error) {
+  currentSession = sess
+  currentProfile = profile
+  assumeYes = yes
+  assumeDryRun = dry
+  ecsSvc := ecs.New(sess)
+  ec2Svc := ec2.New(sess)
+  err := runScriptFile(fileName, vars, func(line string) (error) {
+    return doICommand(line, ecsSvc, ec2Svc, defaultConfig, sess)
+  })
+  if jobs.pending() > 0 { doWaitJobs(0) }
+  return err
+}
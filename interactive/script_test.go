@@ -0,0 +1,52 @@
+package interactive
+
+import(
+  "errors"
+  "io"
+  "strings"
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestScriptExpand(t *testing.T) {
+  s := newScript("test.pilot", map[string]string{"service": "web"})
+  line, err := s.expand("service describe $service ${cluster} costs $$5")
+  assert.NoError(t, err)
+  assert.Equal(t, "service describe web " + currentCluster + " costs $5", line)
+
+  _, err = s.expand("service describe $nonesuch_pilot_var")
+  assert.Error(t, err)
+}
+
+func TestScriptRun(t *testing.T) {
+  text := `
+# Restart web and wait for it.
+set svc=web
+service restart $svc
+bad command
+set -e
+service describe $svc
+fail here
+never run
+`
+  ran := []string{}
+  process := func(line string) (error) {
+    ran = append(ran, line)
+    if strings.HasPrefix(line, "bad") { return errBadCommand }
+    if strings.HasPrefix(line, "fail") { return errors.New("failed") }
+    return nil
+  }
+  err := newScript("test.pilot", nil).run(strings.NewReader(text), process)
+  if assert.Error(t, err, "Should stop on error after set -e.") {
+    assert.Contains(t, err.Error(), "line 8")
+  }
+  assert.Equal(t, []string{"service restart web", "bad command", "service describe web", "fail here"}, ran)
+
+  ran = []string{}
+  quit := func(line string) (error) {
+    ran = append(ran, line)
+    return io.EOF
+  }
+  assert.NoError(t, newScript("test.pilot", nil).run(strings.NewReader("quit\nnever run\n"), quit))
+  assert.Equal(t, []string{"quit"}, ran)
+}
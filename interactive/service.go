@@ -3,6 +3,7 @@ package interactive
 import(
 
   "fmt"
+  "github.com/aws/aws-sdk-go/aws"
   "github.com/aws/aws-sdk-go/aws/session"
   "github.com/aws/aws-sdk-go/service/ecs"
   // "io"
@@ -28,14 +29,17 @@ func doListServices(clusterName string, sess *session.Session) (err error) {
     if len(services) == 0 {
       fmt.Printf("%sThere are no services on this cluster.%s\n", warnColor, resetColor)
     } else {
-      printShortServices(services);
+      names := make([]string, 0, len(services))
+      for _, s := range services { names = append(names, aws.StringValue(s.ServiceName)) }
+      recordResults(serviceRefs, clusterName, names)
+      printNumberedServices(services)
     }
   }
 
   return err
 }
 
-func doDescribeService(serviceName, clusterName string, sess *session.Session) (err error) {
+func doDescribeService(serviceName, clusterName string, window time.Duration, sess *session.Session) (err error) {
 
   s, failures, err := awslib.DescribeService(serviceName, clusterName, sess)
 
@@ -46,26 +50,37 @@ func doDescribeService(serviceName, clusterName string, sess *session.Session) (
 
   if err == nil {
     fmt.Printf("%sService ARN: %s, TaskDefinitinArn: %s%s\n", 
-      titleColor, *s.ServiceArn, *s.TaskDefinition, resetColor)
-    printService(s)
+      titleColor, *s.ServiceArn, aws.StringValue(s.TaskDefinition), resetColor)
+    fmt.Printf("%sDeployment Controller: %s%s\n", titleColor, deploymentControllerType(s), resetColor)
+    printService(s, sess)
+    printServiceUtilization(serviceName, clusterName, window, sess)
   }
   return err
 }
 
 func doCreateService(serviceName, taskDefinitionArn, clusterName string, 
-  instanceCount int64, sess *session.Session) (error) {
+  instanceCount int64, opts taskNetworkOptions, sess *session.Session) (error) {
 
-  service, err := awslib.CreateService(serviceName, clusterName, taskDefinitionArn, instanceCount, sess)
+  var service *ecs.Service
+  var err error
+  if opts.isDefault() {
+    service, err = awslib.CreateService(serviceName, clusterName, taskDefinitionArn, instanceCount, sess)
+  } else {
+    service, err = createServiceWithNetwork(serviceName, clusterName, taskDefinitionArn, instanceCount, opts, sess)
+  }
   if err == nil {
     fmt.Printf("%sCreated Service: %s\n", successColor, resetColor)
     // fmt.Printf("%s%#v%s\n", titleColor, *service, resetColor)
-    printService(service)
+    printService(service, sess)
     fmt.Printf("%sWill notify when the service is stable.%s\n", infoColor, resetColor)
-    awslib.OnServiceStable(serviceName, clusterName, sess, func(error) {
+    job := jobs.start(clusterName, fmt.Sprintf("service %s stable", serviceName))
+    awslib.OnServiceStable(serviceName, clusterName, sess, func(err error) {
+      defer job.finish(err)
+      if job.cancelled() { return }
       if err == nil {
         fmt.Printf("\n%sService is now stable: %s on cluster %s%s\n", successColor, serviceName, clusterName, resetColor)
         s, _, err := awslib.DescribeService(serviceName, clusterName, sess)
-        if err != nil { printService(s) }
+        if err != nil { printService(s, sess) }
       } else {
         fmt.Printf("\n%sError waiting for service to stabilize: %s on cluster %s: %s%s\n", 
           failColor, serviceName, clusterName, err, resetColor)
@@ -83,7 +98,7 @@ func doUpdateServiceDesiredCount(serviceName, clusterName string, instanceCount
   s, err := awslib.UpdateServiceDesiredCount(serviceName, clusterName, instanceCount, sess)
 
   if err == nil {
-    printService(s)
+    printService(s, sess)
     fmt.Printf("%sDesired instance count updated for %s on cluster %s.%s\n", successColor, serviceName, clusterName, resetColor)
   }
   return err
@@ -92,12 +107,18 @@ func doUpdateServiceDesiredCount(serviceName, clusterName string, instanceCount
 func doRestartService(serviceName, clusterName string, sess *session.Session) (error) {
 
   start := time.Now()
+  restarted := jobs.start(clusterName, fmt.Sprintf("restart service %s", serviceName))
   err := awslib.RestartService(serviceName, clusterName, sess, func(s *ecs.Service, err error) {
+    defer restarted.finish(err)
+    if restarted.cancelled() { return }
     if err == nil {
       fmt.Printf("\n%s%sService restarted (%s): %s on cluster %s%s\n", 
         successColor, nowString(), shortDurationString(time.Since(start)), serviceName, clusterName, resetColor)
       fmt.Printf("%sWill update when service is stable.%s\n", infoColor, resetColor)
+      stable := jobs.start(clusterName, fmt.Sprintf("service %s stable after restart", serviceName))
       awslib.OnServiceStable(serviceName, clusterName, sess, func(err error){
+        defer stable.finish(err)
+        if stable.cancelled() { return }
         if err == nil {
           fmt.Printf("\n%s%sService is now stable (%s): %s on cluster %s%s\n", 
             successColor, nowString(), shortDurationString(time.Since(start)), serviceName, clusterName, resetColor)
@@ -119,6 +140,7 @@ func doRestartService(serviceName, clusterName string, sess *session.Session) (e
     }
   })
 
+  if err != nil { restarted.finish(err) }
   if err == nil {
     fmt.Printf("%s%sService restarting: %s on cluster %s%s\n", 
       successColor, nowString(), serviceName, clusterName, resetColor)
@@ -128,15 +150,19 @@ func doRestartService(serviceName, clusterName string, sess *session.Session) (e
   return err
 }
 
-func doDeleteService(serviceName, clusterName string, sess *session.Session) (error) {
+func doDeleteService(serviceName, clusterName string, force bool, sess *session.Session) (error) {
+  if ok, err := confirmDestructive(fmt.Sprintf("Delete service %s on %s", serviceName, clusterName), clusterName, serviceName, force); !ok { return err }
 
   service, err := awslib.DeleteService(serviceName, clusterName, sess)
   if err == nil {
     fmt.Printf("%sDeleted Service: %s\n", successColor, resetColor)
     // fmt.Printf("%s%#v%s\n", titleColor, *service, resetColor)
-    printService(service)
+    printService(service, sess)
     fmt.Printf("%sService deleting. Will update when inactive.%s\n", successColor, resetColor)
-    awslib.OnServiceInactive(serviceName, clusterName, sess, func(error) {
+    job := jobs.start(clusterName, fmt.Sprintf("service %s inactive", serviceName))
+    awslib.OnServiceInactive(serviceName, clusterName, sess, func(err error) {
+      defer job.finish(err)
+      if job.cancelled() { return }
       if err == nil {
         fmt.Printf("\n%sService is now Inactive: %s on cluster %s%s\n", successColor, serviceName, clusterName, resetColor)
       } else {
@@ -150,18 +176,26 @@ func doDeleteService(serviceName, clusterName string, sess *session.Session) (er
 }
 
 
-func doPrintShortServiceHeader() (w *tabwriter.Writer) {
+func doPrintShortServiceHeader() (w *tabwriter.Writer) { return doPrintShortServiceHeaderNumbered(false) }
+
+// Numbered lists start with the # service commands take as #n.
+func doPrintShortServiceHeaderNumbered(numbered bool) (w *tabwriter.Writer) {
+  number := ""
+  if numbered { number = "#\t" }
   w = tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
-  fmt.Fprintf(w, "%sName\tCluster\tTaskDefinition\tRole\tStatus\tCreated\tDesired\tRunning\tPending\tMax%%\tMin%%%s\n", titleColor, resetColor)
+  fmt.Fprintf(w, "%s%sName\tCluster\tTaskDefinition\tRole\tStatus\tCreated\tDesired\tRunning\tPending\tMax%%\tMin%%%s\n", titleColor, number, resetColor)
   return w
 }
 
-func doPrintShortService(w *tabwriter.Writer, s *ecs.Service) {
-  fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d%s\n", nullColor,
+func doPrintShortService(w *tabwriter.Writer, s *ecs.Service) { doPrintShortServiceNumbered(w, "", s) }
+
+func doPrintShortServiceNumbered(w *tabwriter.Writer, number string, s *ecs.Service) {
+  if number != "" { number += "\t" }
+  fmt.Fprintf(w, "%s%s%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d%s\n", nullColor, number,
     *s.ServiceName, awslib.ShortArnString(s.ClusterArn), awslib.ShortArnString(s.TaskDefinition), 
     awslib.ShortArnString(s.RoleArn), *s.Status, s.CreatedAt.Local().Format(time.RFC1123), 
     *s.DesiredCount, *s.RunningCount, *s.PendingCount, 
-    *s.DeploymentConfiguration.MaximumPercent, *s.DeploymentConfiguration.MinimumHealthyPercent,
+    aws.Int64Value(s.DeploymentConfiguration.MaximumPercent), aws.Int64Value(s.DeploymentConfiguration.MinimumHealthyPercent),
     resetColor)
 }
 
@@ -174,7 +208,16 @@ func printShortServices(services []*ecs.Service) {
   }
   w.Flush()
 }
-func printService(s *ecs.Service) {
+
+func printNumberedServices(services []*ecs.Service) {
+  w := doPrintShortServiceHeaderNumbered(true)
+  for i, s := range services {
+    doPrintShortServiceNumbered(w, refNumberString(i), s)
+  }
+  w.Flush()
+}
+
+func printService(s *ecs.Service, sess *session.Session) {
   w := doPrintShortServiceHeader()
   doPrintShortService(w, s)
   w.Flush()
@@ -188,20 +231,38 @@ func printService(s *ecs.Service) {
     fmt.Fprintf(w, "%sName\tContainer\tPort\tTargetGroup%s\n", titleColor, resetColor)
     for _, lb := range s.LoadBalancers {
       fmt.Fprintf(w,"%s%s\t%s\t%d\t%s%s\n", nullColor,
-        *lb.LoadBalancerName, *lb.ContainerName, *lb.ContainerPort, awslib.ShortArnString(lb.TargetGroupArn),
+        aws.StringValue(lb.LoadBalancerName), *lb.ContainerName, *lb.ContainerPort, awslib.ShortArnString(lb.TargetGroupArn),
         resetColor)
     }
     w.Flush()
   }
 
-  // Deployments
-  fmt.Printf("\n%sDeployments (%d) %s\n", titleColor, len(s.Deployments), resetColor)
-  if len(s.Deployments) == 0 {
+  // Deployments, CodeDeploy and external controllers deploy with task sets.
+  if deploymentControllerType(s) != ecs.DeploymentControllerTypeEcs {
+    printTaskSets(s.TaskSets)
+  } else {
+    printDeployments(s.Deployments)
+  }
+
+  // Auto Scaling
+  target, policies, err := getServiceScaling(*s.ServiceName, awslib.ShortArnString(s.ClusterArn), sess)
+  if err == nil {
+    printServiceAutoScaling(target, policies)
+  } else {
+    fmt.Printf("\n%sCouldn't get auto scaling for this service: %s%s\n", warnColor, err, resetColor)
+  }
+
+  printRecentServiceEvents(s)
+}
+
+func printDeployments(deployments []*ecs.Deployment) {
+  fmt.Printf("\n%sDeployments (%d) %s\n", titleColor, len(deployments), resetColor)
+  if len(deployments) == 0 {
     fmt.Printf("There are no deployments for this service.\n")
   } else {
     w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
     fmt.Fprintf(w, "%sId\tTaskDefinition\tCreated\tUpdate\tStatus\tDesired\tRunning\tPending%s\n", titleColor, resetColor)
-    for _, d := range s.Deployments {
+    for _, d := range deployments {
       fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d%s\n", nullColor,
         *d.Id, awslib.ShortArnString(d.TaskDefinition), 
         d.CreatedAt.Local().Format(time.RFC1123), d.UpdatedAt.Local().Format(time.RFC1123),
@@ -210,19 +271,4 @@ func printService(s *ecs.Service) {
     }      
     w.Flush()
   }
-
-  // Events
-  fmt.Printf("\n%sService Events (%d)%s\n", titleColor, len(s.Events), resetColor)
-  if len(s.Events) == 0 {
-    fmt.Printf("There are no service events for this service.\n")
-  } else {
-      w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
-      fmt.Fprintf(w, "%sId\tTime\tMessage%s\n", titleColor, resetColor)
-      for _, e := range s.Events {
-        fmt.Fprintf(w, "%s%s\t%s\t%s%s\n", nullColor, 
-        *e.Id, e.CreatedAt.Local().Format(time.RFC1123), *e.Message, resetColor)
-      }
-      w.Flush()
-  }
 }
-
@@ -0,0 +1,188 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "sort"
+  "strings"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+  "github.com/aws/aws-sdk-go/service/ecs"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const awslogsDriver = "awslogs"
+
+// Stopped tasks that stopped within since, most recent first.
+// ECS only keeps stopped tasks around for about an hour.
+func stoppedTasks(clusterName string, since time.Duration, sess *session.Session) ([]*ecs.Task, error) {
+  arns := make([]*string, 0)
+  err := ecs.New(sess).ListTasksPages(&ecs.ListTasksInput{
+    Cluster: aws.String(clusterName),
+    DesiredStatus: aws.String(ecs.DesiredStatusStopped),
+  }, func(page *ecs.ListTasksOutput, last bool) bool {
+    arns = append(arns, page.TaskArns...)
+    return true
+  })
+  if err != nil || len(arns) == 0 { return []*ecs.Task{}, err }
+  tasks, err := describeTasks(clusterName, arns, sess)
+  if err != nil { return nil, err }
+
+  cutoff := time.Now().Add(-since)
+  recent := make([]*ecs.Task, 0, len(tasks))
+  for _, t := range tasks {
+    if t.StoppedAt == nil || t.StoppedAt.After(cutoff) { recent = append(recent, t) }
+  }
+  sort.Slice(recent, func(i, j int) bool { return stoppedTime(recent[i]).After(stoppedTime(recent[j])) })
+  return recent, nil
+}
+
+// Still stopping tasks don't have a StoppedAt yet.
+func stoppedTime(t *ecs.Task) (time.Time) {
+  if t.StoppedAt != nil { return *t.StoppedAt }
+  if t.StoppingAt != nil { return *t.StoppingAt }
+  return time.Now()
+}
+
+func taskRunTime(t *ecs.Task) (string) {
+  if t.StartedAt == nil { return "never started" }
+  return shortDurationString(stoppedTime(t).Sub(*t.StartedAt))
+}
+
+// Eg. web:137 (OutOfMemoryError), sidecar:0
+func containerExitsString(containers []*ecs.Container) (string) {
+  exits := make([]string, 0, len(containers))
+  for _, c := range containers {
+    e := fmt.Sprintf("%s:-", aws.StringValue(c.Name))
+    if c.ExitCode != nil { e = fmt.Sprintf("%s:%d", aws.StringValue(c.Name), *c.ExitCode) }
+    if c.Reason != nil { e += fmt.Sprintf(" (%s)", *c.Reason) }
+    exits = append(exits, e)
+  }
+  return strings.Join(exits, ", ")
+}
+
+func failedExit(t *ecs.Task) (bool) {
+  for _, c := range t.Containers {
+    if c.ExitCode != nil && *c.ExitCode != 0 { return true }
+  }
+  return false
+}
+
+func doListStoppedTasks(clusterName string, since time.Duration, sess *session.Session) (error) {
+  tasks, err := stoppedTasks(clusterName, since, sess)
+  if err != nil { return err }
+  fmt.Printf("%sCluster: %s, %d tasks stopped in the last %s.%s\n", titleColor, clusterName, len(tasks),
+    shortDurationString(since), resetColor)
+  if len(tasks) == 0 { return nil }
+
+  w := tabwriter.NewWriter(os.Stdout, 4, 10, 2, ' ', 0)
+  fmt.Fprintf(w, "%s#\tStopped\tRan\tTask\tTask Definition\tGroup\tStop Code\tStopped Reason\tExits%s\n", titleColor, resetColor)
+  arns := make([]string, 0, len(tasks))
+  for i, t := range tasks {
+    arns = append(arns, aws.StringValue(t.TaskArn))
+    color := nullColor
+    if failedExit(t) { color = failColor }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", color, refNumberString(i),
+      stoppedTime(t).Local().Format(humanTimeFormat), taskRunTime(t), awslib.ShortArnString(t.TaskArn),
+      awslib.ShortArnString(t.TaskDefinitionArn), aws.StringValue(t.Group), aws.StringValue(t.StopCode),
+      aws.StringValue(t.StoppedReason), containerExitsString(t.Containers), resetColor)
+  }
+  w.Flush()
+  recordResults(taskRefs, clusterName, arns)
+  return nil
+}
+
+// Why did this task stop? The stop and exit details with the end of each container's logs.
+func doWhyTask(taskArn, clusterName string, lines int64, sess *session.Session) (error) {
+  resp, err := ecs.New(sess).DescribeTasks(&ecs.DescribeTasksInput{
+    Cluster: aws.String(clusterName),
+    Tasks: []*string{aws.String(taskArn)},
+  })
+  if err != nil { return err }
+  if len(resp.Tasks) == 0 {
+    if len(resp.Failures) > 0 { printFailures(resp.Failures) }
+    return fmt.Errorf("Task %s not found on cluster %s, ECS only keeps stopped tasks for about an hour", taskArn, clusterName)
+  }
+  t := resp.Tasks[0]
+
+  fmt.Printf("%sTask %s (%s) on cluster %s%s\n", titleColor, awslib.ShortArnString(t.TaskArn),
+    awslib.ShortArnString(t.TaskDefinitionArn), clusterName, resetColor)
+  w := tabwriter.NewWriter(os.Stdout, 4, 10, 2, ' ', 0)
+  fmt.Fprintf(w, "%sStatus\tDesired\tStarted\tStopped\tRan\tStop Code\tStopped Reason%s\n", titleColor, resetColor)
+  started, stopped := "-", "-"
+  if t.StartedAt != nil { started = t.StartedAt.Local().Format(humanTimeFormat) }
+  if t.StoppedAt != nil { stopped = t.StoppedAt.Local().Format(humanTimeFormat) }
+  fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor, aws.StringValue(t.LastStatus), aws.StringValue(t.DesiredStatus),
+    started, stopped, taskRunTime(t), aws.StringValue(t.StopCode), aws.StringValue(t.StoppedReason), resetColor)
+  w.Flush()
+
+  fmt.Printf("\n%sContainers%s\n", titleColor, resetColor)
+  w = tabwriter.NewWriter(os.Stdout, 4, 10, 2, ' ', 0)
+  fmt.Fprintf(w, "%sName\tStatus\tExit Code\tReason\tImage%s\n", titleColor, resetColor)
+  for _, c := range t.Containers {
+    color, exit := nullColor, "-"
+    if c.ExitCode != nil {
+      exit = fmt.Sprintf("%d", *c.ExitCode)
+      if *c.ExitCode != 0 { color = failColor }
+    }
+    fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s%s\n", color, aws.StringValue(c.Name), aws.StringValue(c.LastStatus),
+      exit, aws.StringValue(c.Reason), aws.StringValue(c.Image), resetColor)
+  }
+  w.Flush()
+
+  td, err := awslib.GetTaskDefinition(*t.TaskDefinitionArn, sess)
+  if err != nil { return err }
+  for _, cd := range td.ContainerDefinitions {
+    printContainerLogTail(t, cd, lines, sess)
+  }
+  return nil
+}
+
+// Only awslogs can be read back. The stream is prefix/container-name/task-id.
+func printContainerLogTail(t *ecs.Task, cd *ecs.ContainerDefinition, lines int64, sess *session.Session) {
+  name := aws.StringValue(cd.Name)
+  lc := cd.LogConfiguration
+  if lc == nil || aws.StringValue(lc.LogDriver) != awslogsDriver {
+    fmt.Printf("\n%sContainer %s doesn't log to CloudWatch, no logs to show.%s\n", warnColor, name, resetColor)
+    return
+  }
+  group := aws.StringValue(lc.Options["awslogs-group"])
+  prefix := aws.StringValue(lc.Options["awslogs-stream-prefix"])
+  if prefix == "" {
+    fmt.Printf("\n%sContainer %s logs to %s without a stream prefix, can't find its stream.%s\n", warnColor, name, group, resetColor)
+    return
+  }
+  taskId := awslib.ShortArnString(t.TaskArn)
+  if i := strings.LastIndex(taskId, "/"); i >= 0 { taskId = taskId[i+1:] }
+  stream := fmt.Sprintf("%s/%s/%s", prefix, name, taskId)
+
+  logSess := sess
+  if region := aws.StringValue(lc.Options["awslogs-region"]); region != "" && region != aws.StringValue(sess.Config.Region) {
+    logSess = sess.Copy(&aws.Config{Region: aws.String(region)})
+  }
+  resp, err := cloudwatchlogs.New(logSess).GetLogEvents(&cloudwatchlogs.GetLogEventsInput{
+    LogGroupName: aws.String(group),
+    LogStreamName: aws.String(stream),
+    Limit: aws.Int64(lines),
+    StartFromHead: aws.Bool(false),
+  })
+  fmt.Printf("\n%sLast %d log lines for %s (%s %s):%s\n", titleColor, lines, name, group, stream, resetColor)
+  if err != nil {
+    fmt.Printf("%sCan't get logs: %s%s\n", failColor, err, resetColor)
+    return
+  }
+  if len(resp.Events) == 0 {
+    fmt.Printf("No log events.\n")
+    return
+  }
+  for _, e := range resp.Events {
+    ts := time.Unix(0, aws.Int64Value(e.Timestamp) * int64(time.Millisecond))
+    fmt.Printf("%s%s%s %s\n", emphColor, ts.Local().Format("15:04:05"), resetColor,
+      strings.TrimRight(aws.StringValue(e.Message), "\n"))
+  }
+}
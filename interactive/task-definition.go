@@ -5,6 +5,7 @@ import (
   "os"
   "sort"
   "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
   "github.com/aws/aws-sdk-go/aws/session"
   "github.com/aws/aws-sdk-go/service/ecs"
 
@@ -19,11 +20,12 @@ func doListTaskDefinitions(sess *session.Session) (error) {
   tds, err := awslib.ListTaskDefinitionFamilies(sess)
   if err == nil {
     w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
-    fmt.Fprintf(w, "%sTask Definition%s\n", titleColor, resetColor)
-    for _, tdf := range tds {
-      fmt.Fprintf(w,"%s%s%s\n", nullColor, *tdf, resetColor)
+    fmt.Fprintf(w, "%s#\tTask Definition%s\n", titleColor, resetColor)
+    for i, tdf := range tds {
+      fmt.Fprintf(w,"%s%s\t%s%s\n", nullColor, refNumberString(i), *tdf, resetColor)
     }
     w.Flush()
+    recordResults(taskDefinitionRefs, "", aws.StringValueSlice(tds))
   }
   return err
 }
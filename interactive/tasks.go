@@ -7,6 +7,7 @@ import (
   "strings"
   "time"
   "text/tabwriter"
+  "github.com/aws/aws-sdk-go/aws"
   "github.com/aws/aws-sdk-go/aws/session"
   "github.com/aws/aws-sdk-go/service/ecs"
 
@@ -22,12 +23,14 @@ func doListTasks(clusterName string, sess *session.Session) (error) {
   fmt.Printf("%sCluster: %s%s\n", titleColor, currentCluster, resetColor)
   if len(dtl) > 0 {
     w := tabwriter.NewWriter(os.Stdout, 4, 10, 2, ' ', 0)
-    fmt.Fprintf(w, "%sPublic\tTask ARN\tTask Definition\tContainers\tBindings%s\n", titleColor, resetColor)
+    fmt.Fprintf(w, "%s#\tPublic\tTask ARN\tTask Definition\tContainers\tBindings%s\n", titleColor, resetColor)
     sort.Sort(awslib.ByStartedAt(dtl))
-    for _, dt := range dtl {
+    recordDeepTasks(currentCluster, dtl)
+    addrs := deepTaskAddresses(dtl, sess)
+    for i, dt := range dtl {
       t := dt.Task
-      fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s%s\n", nullColor, 
-        dt.PublicIpAddress(), awslib.ShortArnString(dt.Task.TaskArn),
+      fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor, refNumberString(i),
+        addrs[*t.TaskArn].Public, awslib.ShortArnString(dt.Task.TaskArn),
         awslib.ShortArnString(t.TaskDefinitionArn),  awslib.CollectContainerNames(t.Containers), 
         awslib.CollectBindings(t), 
         resetColor)
@@ -45,12 +48,14 @@ func doStatusTasks(clusterName string, sess *session.Session) (error) {
   fmt.Printf("%sCluster: %s%s\n", titleColor, currentCluster, resetColor)
   if len(dtl) > 0 {
     w := tabwriter.NewWriter(os.Stdout, 4, 10, 2, ' ', 0)
-    fmt.Fprintf(w, "%sPublic\tPrivate\tContainers\tUptime\tTTS\tStatus\tTask Definition%s\n", titleColor, resetColor)
+    fmt.Fprintf(w, "%s#\tPublic\tPrivate\tContainers\tUptime\tTTS\tStatus\tTask Definition%s\n", titleColor, resetColor)
     sort.Sort(awslib.ByStartedAt(dtl))
-    for _, dt := range dtl {
+    recordDeepTasks(currentCluster, dtl)
+    addrs := deepTaskAddresses(dtl, sess)
+    for i, dt := range dtl {
       t := dt.Task
-      fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor, 
-        dt.PublicIpAddress(), dt.PrivateIpAddress(), awslib.CollectContainerNames(t.Containers), dt.UptimeString(), dt.TimeToStartString(),
+      fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor, refNumberString(i),
+        addrs[*t.TaskArn].Public, addrs[*t.TaskArn].Private, awslib.CollectContainerNames(t.Containers), dt.UptimeString(), dt.TimeToStartString(),
         *t.LastStatus, awslib.ShortArnString(t.TaskDefinitionArn), resetColor)
     }
     w.Flush()
@@ -60,10 +65,16 @@ func doStatusTasks(clusterName string, sess *session.Session) (error) {
   return nil
 }
 
+func recordDeepTasks(clusterName string, dtl []*awslib.DeepTask) {
+  arns := make([]string, 0, len(dtl))
+  for _, dt := range dtl { arns = append(arns, aws.StringValue(dt.Task.TaskArn)) }
+  recordResults(taskRefs, clusterName, arns)
+}
+
 func doDescribeTask(sess *session.Session) (error) {
   dt, err := awslib.GetDeepTask(currentCluster, interTaskArn, sess)
   if err == nil { 
-    printDeepTask(dt)
+    printDeepTask(dt, sess)
   }
   return nil
 }
@@ -77,7 +88,7 @@ func doDescribeAllTasks(sess *session.Session) (error) {
       sort.Sort(sort.Reverse(awslib.ByUptime(dtl)))
       for _, dt := range dtl {
         fmt.Printf("\n%sTask: %s%s\n", infoColor, *dt.Task.TaskArn, resetColor)
-        printDeepTask(dt)
+        printDeepTask(dt, sess)
       }
     }
   }
@@ -85,19 +96,24 @@ func doDescribeAllTasks(sess *session.Session) (error) {
 }
 
 // yes, yes. Cut this up into smaller fuctions ....
-func printDeepTask(dt *awslib.DeepTask) {
+func printDeepTask(dt *awslib.DeepTask, sess *session.Session) {
 
   // Task description
   fmt.Printf("\n")
   // fmt.Printf("%sDescription%s\n", titleColor, resetColor)
   w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
-  fmt.Fprintf(w, "%sCluster\tTaskDefinintion\tARN\tInstnanceID\tTaskRole\tPublicIP\tPrivateIP\tNetwork Mode%s\n", titleColor, resetColor) 
+  fmt.Fprintf(w, "%sCluster\tTaskDefinintion\tARN\tLaunch Type\tInstnanceID\tTaskRole\tPublicIP\tPrivateIP\tNetwork Mode%s\n", titleColor, resetColor) 
   roleArn := "<none>"
   if dt.TaskDefinition.TaskRoleArn != nil { roleArn = *dt.TaskDefinition.TaskRoleArn }
-  fmt.Fprintf(w,"%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor,
+  launchType := ecs.LaunchTypeEc2
+  if dt.Task.LaunchType != nil { launchType = *dt.Task.LaunchType }
+  instanceId := "<none>"
+  if launchType != ecs.LaunchTypeFargate && dt.GetInstanceID() != nil { instanceId = *dt.GetInstanceID() }
+  addr := deepTaskAddress(dt, sess)
+  fmt.Fprintf(w,"%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", nullColor,
     dt.ClusterName(), awslib.ShortArnString(dt.TaskDefinition.TaskDefinitionArn), awslib.ShortArnString(dt.Task.TaskArn), 
-    *dt.GetInstanceID(), roleArn, dt.PublicIpAddress(), dt.PrivateIpAddress(), 
-    *dt.TaskDefinition.NetworkMode, resetColor)
+    launchType, instanceId, roleArn, addr.Public, addr.Private,
+    aws.StringValue(dt.TaskDefinition.NetworkMode), resetColor)
   w.Flush()
 
   // Status
@@ -120,7 +136,9 @@ func printDeepTask(dt *awslib.DeepTask) {
     w = tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
     fmt.Fprintf(w, "%sName\tHost Source Path%s\n", titleColor, resetColor)
     for _, v := range dt.TaskDefinition.Volumes {
-      fmt.Fprintf(w,"%s%s\t%s%s\n", nullColor, *v.Name, *v.Host.SourcePath, resetColor)
+      sourcePath := "<none>"
+      if v.Host != nil && v.Host.SourcePath != nil { sourcePath = *v.Host.SourcePath }
+      fmt.Fprintf(w,"%s%s\t%s%s\n", nullColor, *v.Name, sourcePath, resetColor)
     }
   } else {
     fmt.Printf("No volumes specified.\n")
@@ -198,7 +216,8 @@ func printDeepTask(dt *awslib.DeepTask) {
   fmt.Fprintf(w, "%sContainer\tCPU\tMemory Limit\tMemory Reservation%s\n", titleColor, resetColor)
   for _, c := range dt.Task.Containers {
     cdef, _ := awslib.GetContainerDefinition(*c.Name, dt.TaskDefinition)
-    fmt.Fprintf(w,"%s%s\t%d\t%d\t%d%s\n", nullColor, *c.Name, *cdef.Cpu, *cdef.Memory, *cdef.MemoryReservation, resetColor)
+    fmt.Fprintf(w,"%s%s\t%d\t%d\t%d%s\n", nullColor, *c.Name, aws.Int64Value(cdef.Cpu), 
+      aws.Int64Value(cdef.Memory), aws.Int64Value(cdef.MemoryReservation), resetColor)
   }
   w.Flush()
 
@@ -345,8 +364,10 @@ func mergeEnvs(envs map[string]map[string]string) (el []*taskEnvEntry) {
 }
 
 func doRunTask(sess *session.Session) (error) {
+  clusterName := currentCluster
   // svc := ecs.New(sess)
   containerEnvMap := make(awslib.ContainerEnvironmentMap)
+  if err := readFileValues(taskEnv); err != nil { return err }
   if len(taskEnv) > 0 {
     taskDef, err  := awslib.GetTaskDefinition(taskDefinitionArnArg, sess)
     if err != nil {
@@ -360,20 +381,30 @@ func doRunTask(sess *session.Session) (error) {
     }
   }
 
-  runTaskOut, err := awslib.RunTaskWithEnv(currentCluster, taskDefinitionArnArg, containerEnvMap, sess)
+  var runTaskOut *ecs.RunTaskOutput
+  var err error
+  netOpts := taskNetworkArgs.withLists()
+  if netOpts.isDefault() {
+    runTaskOut, err = awslib.RunTaskWithEnv(clusterName, taskDefinitionArnArg, containerEnvMap, sess)
+  } else {
+    runTaskOut, err = runTaskWithNetwork(clusterName, taskDefinitionArnArg, containerEnvMap, netOpts, sess)
+  }
   if err == nil {
     fmt.Printf("%sStarting task.%s\n", successColor, resetColor)
     printTaskDescription(runTaskOut.Tasks, runTaskOut.Failures, false)
     if len(runTaskOut.Tasks) > 0 {
       taskToWaitOn := *runTaskOut.Tasks[0].TaskArn
-      awslib.OnTaskRunning(currentCluster, taskToWaitOn, sess, func(taskDescrip *ecs.DescribeTasksOutput, err error) {
+      job := jobs.start(clusterName, fmt.Sprintf("task %s running", awslib.ShortArnString(&taskToWaitOn)))
+      awslib.OnTaskRunning(clusterName, taskToWaitOn, sess, func(taskDescrip *ecs.DescribeTasksOutput, err error) {
+        defer job.finish(err)
+        if job.cancelled() { return }
         if err == nil {
-          fmt.Printf("\n%sTask is now running on cluster %s%s\n", successColor, currentCluster, resetColor)
+          fmt.Printf("\n%sTask is now running on cluster %s%s\n", successColor, clusterName, resetColor)
           printTaskDescription(taskDescrip.Tasks, taskDescrip.Failures, true)
           fmt.Printf("%s\n", containerEnvironmentsString(&containerEnvMap))
         } else {
           fmt.Printf("\n%sProblem starting task: %s on cluster %s.%s\n", 
-            failColor, awslib.ShortArnString(&taskToWaitOn), currentCluster, resetColor)
+            failColor, awslib.ShortArnString(&taskToWaitOn), clusterName, resetColor)
           fmt.Printf("%sError: %s.%s\n", warnColor, err, resetColor)
           if taskDescrip != nil {
             tasks := taskDescrip.Tasks
@@ -416,9 +447,12 @@ func doRunTask(sess *session.Session) (error) {
   return err
 }
 
-func doStopTask(sess *session.Session) (error) {
-  fmt.Printf("%sStopping the task: %s%s\n", warnColor, interTaskArn, resetColor)
-  resp, err := awslib.StopTask(currentCluster, interTaskArn, sess)
+func doStopTask(force bool, sess *session.Session) (error) {
+  clusterName, taskArn := currentCluster, interTaskArn
+  question := fmt.Sprintf("Stop task %s on %s", awslib.ShortArnString(&taskArn), clusterName)
+  if ok, err := confirmDestructive(question, clusterName, "", force); !ok { return err }
+  fmt.Printf("%sStopping the task: %s%s\n", warnColor, taskArn, resetColor)
+  resp, err := awslib.StopTask(clusterName, taskArn, sess)
   if err == nil {
     t := resp.Task
     fmt.Printf("%sTask scheduled to stop.\n%s", successColor, resetColor)
@@ -435,12 +469,15 @@ func doStopTask(sess *session.Session) (error) {
       resetColor)
     w.Flush()
 
-    awslib.OnTaskStopped(currentCluster, interTaskArn, sess, func(dto *ecs.DescribeTasksOutput, err error){
+    job := jobs.start(clusterName, fmt.Sprintf("task %s stopped", awslib.ShortArnString(&taskArn)))
+    awslib.OnTaskStopped(clusterName, taskArn, sess, func(dto *ecs.DescribeTasksOutput, err error){
+      defer job.finish(err)
+      if job.cancelled() { return }
       if err == nil {
-        fmt.Printf("\n%sTask: %s: %s is now stopped.%s\n", warnColor, currentCluster, interTaskArn,resetColor)
+        fmt.Printf("\n%sTask: %s: %s is now stopped.%s\n", warnColor, clusterName, taskArn,resetColor)
       } else {
         fmt.Printf("\n%sThere was a problem waiting for task %s on cluster %s to stop.%s\n", 
-          failColor, interTaskArn, currentCluster, resetColor)
+          failColor, taskArn, clusterName, resetColor)
         fmt.Printf("\n%sError: %s.%s\n", warnColor, err, resetColor)
       }
     })
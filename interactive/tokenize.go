@@ -0,0 +1,147 @@
+package interactive
+
+import (
+  "bytes"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "sort"
+  "strings"
+  "text/tabwriter"
+)
+
+// Splits a command line into words the way a shell would: on whitespace, except
+// inside single quotes (taken literally) and double quotes (where \ escapes " and \).
+// Outside quotes \ escapes the next character. Quotes can start mid word, so
+// FOO="a b" is the single word FOO=a b.
+func tokenize(line string) ([]string, error) {
+  words := make([]string, 0)
+  var word bytes.Buffer
+  inWord := false
+  runes := []rune(line)
+  for i := 0; i < len(runes); i++ {
+    r := runes[i]
+    switch {
+    case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+      if inWord {
+        words = append(words, word.String())
+        word.Reset()
+        inWord = false
+      }
+    case r == '\\':
+      inWord = true
+      if i+1 < len(runes) {
+        i++
+        word.WriteRune(runes[i])
+      }
+    case r == '\'':
+      inWord = true
+      end := indexRune(runes, i+1, '\'')
+      if end < 0 { return nil, fmt.Errorf("Missing closing ' in: %s", line) }
+      word.WriteString(string(runes[i+1:end]))
+      i = end
+    case r == '"':
+      inWord = true
+      closed := false
+      for i++; i < len(runes); i++ {
+        if runes[i] == '"' {
+          closed = true
+          break
+        }
+        if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') { i++ }
+        word.WriteRune(runes[i])
+      }
+      if !closed { return nil, fmt.Errorf("Missing closing \" in: %s", line) }
+    default:
+      inWord = true
+      word.WriteRune(r)
+    }
+  }
+  if inWord { words = append(words, word.String()) }
+  return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) (int) {
+  for i := from; i < len(runes); i++ {
+    if runes[i] == r { return i }
+  }
+  return -1
+}
+
+// Replaces KEY=@file values with the contents of file, less a trailing newline.
+// @@ at the start of a value is a literal @.
+func readFileValues(values map[string]string) (error) {
+  for k, v := range values {
+    switch {
+    case strings.HasPrefix(v, "@@"):
+      values[k] = v[1:]
+    case strings.HasPrefix(v, "@"):
+      b, err := ioutil.ReadFile(v[1:])
+      if err != nil { return fmt.Errorf("Can't read the value of %s: %s", k, err) }
+      values[k] = strings.TrimSuffix(string(b), "\n")
+    }
+  }
+  return nil
+}
+
+// Aliases from the config file, and those made with the alias command for the session.
+var aliases map[string]string
+
+func getAliases() (map[string]string) {
+  if aliases == nil {
+    aliases = make(map[string]string)
+    if config, err := getConfig(); err == nil {
+      for name, expansion := range config.Aliases { aliases[name] = expansion }
+    } else {
+      fmt.Printf("%sNo aliases from the config file: %s%s\n", warnColor, err, resetColor)
+    }
+  }
+  return aliases
+}
+
+// Replaces an alias in the first word with its words. Aliases aren't expanded again,
+// so an alias can add flags to the command it's named for.
+func expandAlias(words []string, aliases map[string]string) ([]string, error) {
+  if len(words) == 0 { return words, nil }
+  expansion, ok := aliases[words[0]]
+  if !ok { return words, nil }
+  expanded, err := tokenize(expansion)
+  if err != nil { return nil, fmt.Errorf("Bad alias %s: %s", words[0], err) }
+  return append(expanded, words[1:]...), nil
+}
+
+// alias lists them, alias name=expansion sets one, alias name= removes it.
+func doAlias(definition string) (error) {
+  aliases := getAliases()
+  if definition == "" {
+    if len(aliases) == 0 {
+      fmt.Printf("There are no aliases.\n")
+      return nil
+    }
+    names := make([]string, 0, len(aliases))
+    for name := range aliases { names = append(names, name) }
+    sort.Strings(names)
+    w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+    fmt.Fprintf(w, "%sAlias\tCommand%s\n", titleColor, resetColor)
+    for _, name := range names {
+      fmt.Fprintf(w, "%s%s\t%s%s\n", nullColor, name, aliases[name], resetColor)
+    }
+    w.Flush()
+    return nil
+  }
+
+  kv := strings.SplitN(definition, "=", 2)
+  name := strings.TrimSpace(kv[0])
+  if len(kv) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+    return fmt.Errorf("Define an alias as name=\"command words\"")
+  }
+  if kv[1] == "" {
+    delete(aliases, name)
+    fmt.Printf("Removed alias %s.\n", name)
+    return nil
+  }
+  if _, err := tokenize(kv[1]); err != nil { return err }
+  aliases[name] = kv[1]
+  fmt.Printf("%s is now %s.\n", name, kv[1])
+  return nil
+}
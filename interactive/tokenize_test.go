@@ -0,0 +1,67 @@
+package interactive
+
+import(
+  "io/ioutil"
+  "os"
+  "testing"
+  "github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+  tests := []struct {
+    line string
+    words []string
+  }{
+    {"task run web", []string{"task", "run", "web"}},
+    {"  task   run\tweb  ", []string{"task", "run", "web"}},
+    {`task run web FOO="a b" BAR='c d'`, []string{"task", "run", "web", "FOO=a b", "BAR=c d"}},
+    {`task run web JSON='{"a": [1, 2]}'`, []string{"task", "run", "web", `JSON={"a": [1, 2]}`}},
+    {`echo "say \"hi\" \\ \n"`, []string{"echo", `say "hi" \ \n`}},
+    {`echo a\ b 'it\'s'`, nil},
+    {`echo a\ b "it's" ''`, []string{"echo", "a b", "it's", ""}},
+    {"", []string{}},
+  }
+  for _, test := range tests {
+    words, err := tokenize(test.line)
+    if test.words == nil {
+      assert.Error(t, err, test.line)
+      continue
+    }
+    if assert.NoError(t, err, test.line) { assert.Equal(t, test.words, words, test.line) }
+  }
+
+  _, err := tokenize(`task run web FOO="a b`)
+  assert.Error(t, err, "Should need the closing quote.")
+}
+
+func TestExpandAlias(t *testing.T) {
+  aliases := map[string]string{"st": "task status", "list": "list --verbose", "bad": "oops '"}
+  words, err := expandAlias([]string{"st", "prod"}, aliases)
+  assert.NoError(t, err)
+  assert.Equal(t, []string{"task", "status", "prod"}, words)
+
+  words, err = expandAlias([]string{"list"}, aliases)
+  assert.NoError(t, err)
+  assert.Equal(t, []string{"list", "--verbose"}, words, "Should only expand once.")
+
+  words, err = expandAlias([]string{"task", "st"}, aliases)
+  assert.NoError(t, err)
+  assert.Equal(t, []string{"task", "st"}, words, "Should only expand the first word.")
+
+  _, err = expandAlias([]string{"bad"}, aliases)
+  assert.Error(t, err)
+}
+
+func TestReadFileValues(t *testing.T) {
+  f, err := ioutil.TempFile("", "ecs-pilot-value")
+  if !assert.NoError(t, err) { return }
+  defer os.Remove(f.Name())
+  f.WriteString("secret value\n")
+  f.Close()
+
+  values := map[string]string{"FILE": "@" + f.Name(), "AT": "@@home", "PLAIN": "plain"}
+  assert.NoError(t, readFileValues(values))
+  assert.Equal(t, map[string]string{"FILE": "secret value", "AT": "@home", "PLAIN": "plain"}, values)
+
+  assert.Error(t, readFileValues(map[string]string{"MISSING": "@/no/such/ecs-pilot/file"}))
+}
@@ -0,0 +1,60 @@
+package interactive
+
+import (
+  "fmt"
+  "os"
+  "text/tabwriter"
+  "time"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "ecs-pilot/metrics"
+)
+
+// Utilization is a nice to have in the views, so failures to get it
+// (eg. no CloudWatch permissions) are reported and not returned.
+func printClusterUtilization(clusterName string, window time.Duration, sess *session.Session) {
+  u, err := metrics.ClusterUtilization(clusterName, window, sess)
+  if err != nil {
+    fmt.Printf("%s%s%s\n", warnColor, err, resetColor)
+    return
+  }
+  printUtilization(fmt.Sprintf("Cluster \"%s\"", clusterName), u, window)
+}
+
+func printServiceUtilization(serviceName, clusterName string, window time.Duration, sess *session.Session) {
+  us, err := metrics.ServiceUtilization(clusterName, []string{serviceName}, window, sess)
+  if err != nil {
+    fmt.Printf("%s%s%s\n", warnColor, err, resetColor)
+    return
+  }
+  printUtilization(fmt.Sprintf("Service \"%s\"", serviceName), us[serviceName], window)
+}
+
+func printUtilization(title string, u *metrics.Utilization, window time.Duration) {
+  fmt.Printf("\n%s%s utilization over the last %s:%s\n", titleColor, title, shortDurationString(window), resetColor)
+  if u.CPU.Empty() && u.Memory.Empty() {
+    fmt.Printf("No CloudWatch data for this window.\n")
+    return
+  }
+  w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+  fmt.Fprintf(w, "%sMetric\tNow\tAvg\tMax\tHistory%s\n", titleColor, resetColor)
+  printSeriesLine(w, "CPU", u.CPU)
+  printSeriesLine(w, "Memory", u.Memory)
+  w.Flush()
+}
+
+func printSeriesLine(w *tabwriter.Writer, name string, s *metrics.Series) {
+  if s.Empty() {
+    fmt.Fprintf(w, "%s%s\t-\t-\t-\t%s\n", nullColor, name, resetColor)
+    return
+  }
+  fmt.Fprintf(w, "%s%s\t%.1f%%\t%.1f%%\t%.1f%%\t|%s|%s\n", utilizationColor(s.Latest()), name,
+    s.Latest(), s.Average(), s.Max(), s.Sparkline(100), resetColor)
+}
+
+func utilizationColor(percent float64) (string) {
+  switch {
+  case percent >= 90: return failColor
+  case percent >= 75: return warnColor
+  }
+  return nullColor
+}
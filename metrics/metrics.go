@@ -0,0 +1,196 @@
+// Package metrics fetches CloudWatch utilization for clusters, services and
+// EC2 instances, shared by the interactive views and the server.
+package metrics
+
+import (
+  "fmt"
+  "math"
+  "time"
+  "github.com/aws/aws-sdk-go/aws"
+  "github.com/aws/aws-sdk-go/aws/session"
+  "github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const (
+  EcsNamespace = "AWS/ECS"
+  Ec2Namespace = "AWS/EC2"
+  CPUUtilization = "CPUUtilization"
+  MemoryUtilization = "MemoryUtilization"
+
+  DefaultWindow = time.Hour
+
+  // Roughly how many points to get back for a window.
+  targetPoints = 30
+  // GetMetricData takes at most this many queries at a time.
+  maxQueries = 500
+)
+
+// Levels for sparklines, from lowest to highest.
+const sparkLevels = " .:-=+*#%@"
+
+// One metric over the window, oldest point first.
+type Series struct {
+  Label string `json:"label" locationName:"label"`
+  Namespace string `json:"namespace" locationName:"namespace"`
+  Metric string `json:"metric" locationName:"metric"`
+  Timestamps []*time.Time `json:"timestamps" locationName:"timestamps"`
+  Values []*float64 `json:"values" locationName:"values"`
+}
+
+func (s *Series) Empty() (bool) {
+  return s == nil || len(s.Values) == 0
+}
+
+func (s *Series) Latest() (float64) {
+  if s.Empty() { return 0 }
+  return aws.Float64Value(s.Values[len(s.Values)-1])
+}
+
+func (s *Series) Average() (float64) {
+  if s.Empty() { return 0 }
+  sum := 0.0
+  for _, v := range s.Values { sum += aws.Float64Value(v) }
+  return sum / float64(len(s.Values))
+}
+
+func (s *Series) Max() (float64) {
+  if s.Empty() { return 0 }
+  max := math.Inf(-1)
+  for _, v := range s.Values { max = math.Max(max, aws.Float64Value(v)) }
+  return max
+}
+
+// An ASCII sparkline of the values scaled from 0 to top, one character per point.
+func (s *Series) Sparkline(top float64) (string) {
+  if s.Empty() { return "" }
+  if top <= 0 { top = s.Max() }
+  line := make([]byte, len(s.Values))
+  for i, v := range s.Values {
+    level := 0
+    if top > 0 {
+      level = int(math.Ceil(aws.Float64Value(v) / top * float64(len(sparkLevels)-1)))
+    }
+    if level < 0 { level = 0 }
+    if level >= len(sparkLevels) { level = len(sparkLevels) - 1 }
+    line[i] = sparkLevels[level]
+  }
+  return string(line)
+}
+
+// Utilization percentages for a whole cluster or a service.
+type Utilization struct {
+  CPU *Series `json:"cpu" locationName:"cpu"`
+  Memory *Series `json:"memory" locationName:"memory"`
+}
+
+type query struct {
+  label string
+  namespace string
+  metric string
+  dimensions map[string]string
+}
+
+// A period giving about targetPoints over the window, in whole minutes
+// as that's CloudWatch's basic resolution.
+func Period(window time.Duration) (int64) {
+  minutes := int64(math.Ceil(window.Minutes() / targetPoints))
+  if minutes < 1 { minutes = 1 }
+  return minutes * 60
+}
+
+// Average of each query over the window. The series come back in query order.
+func fetch(queries []query, window time.Duration, sess *session.Session) ([]*Series, error) {
+  cw := cloudwatch.New(sess)
+  end := time.Now()
+  start := end.Add(-window)
+  series := make([]*Series, len(queries))
+  for i, q := range queries {
+    series[i] = &Series{Label: q.label, Namespace: q.namespace, Metric: q.metric}
+  }
+
+  for first := 0; first < len(queries); first += maxQueries {
+    last := first + maxQueries
+    if last > len(queries) { last = len(queries) }
+    mdqs := make([]*cloudwatch.MetricDataQuery, 0, last-first)
+    for i := first; i < last; i++ {
+      q := queries[i]
+      dims := make([]*cloudwatch.Dimension, 0, len(q.dimensions))
+      for k, v := range q.dimensions {
+        dims = append(dims, &cloudwatch.Dimension{Name: aws.String(k), Value: aws.String(v)})
+      }
+      mdqs = append(mdqs, &cloudwatch.MetricDataQuery{
+        Id: aws.String(fmt.Sprintf("q%d", i)),
+        MetricStat: &cloudwatch.MetricStat{
+          Metric: &cloudwatch.Metric{Namespace: aws.String(q.namespace), MetricName: aws.String(q.metric), Dimensions: dims},
+          Period: aws.Int64(Period(window)),
+          Stat: aws.String(cloudwatch.StatisticAverage),
+        },
+      })
+    }
+    err := cw.GetMetricDataPages(&cloudwatch.GetMetricDataInput{
+      StartTime: aws.Time(start),
+      EndTime: aws.Time(end),
+      ScanBy: aws.String(cloudwatch.ScanByTimestampAscending),
+      MetricDataQueries: mdqs,
+    }, func(page *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+      for _, r := range page.MetricDataResults {
+        var i int
+        if _, err := fmt.Sscanf(aws.StringValue(r.Id), "q%d", &i); err != nil || i >= len(series) { continue }
+        series[i].Timestamps = append(series[i].Timestamps, r.Timestamps...)
+        series[i].Values = append(series[i].Values, r.Values...)
+      }
+      return true
+    })
+    if err != nil { return nil, fmt.Errorf("Can't get CloudWatch metrics: %s", err) }
+  }
+  return series, nil
+}
+
+func ClusterUtilization(clusterName string, window time.Duration, sess *session.Session) (*Utilization, error) {
+  dims := map[string]string{"ClusterName": clusterName}
+  series, err := fetch([]query{
+    {label: clusterName, namespace: EcsNamespace, metric: CPUUtilization, dimensions: dims},
+    {label: clusterName, namespace: EcsNamespace, metric: MemoryUtilization, dimensions: dims},
+  }, window, sess)
+  if err != nil { return nil, err }
+  return &Utilization{CPU: series[0], Memory: series[1]}, nil
+}
+
+// Utilization for each of the services, keyed by service name.
+func ServiceUtilization(clusterName string, serviceNames []string, window time.Duration, sess *session.Session) (map[string]*Utilization, error) {
+  queries := make([]query, 0, 2*len(serviceNames))
+  for _, name := range serviceNames {
+    dims := map[string]string{"ClusterName": clusterName, "ServiceName": name}
+    queries = append(queries,
+      query{label: name, namespace: EcsNamespace, metric: CPUUtilization, dimensions: dims},
+      query{label: name, namespace: EcsNamespace, metric: MemoryUtilization, dimensions: dims})
+  }
+  series, err := fetch(queries, window, sess)
+  if err != nil { return nil, err }
+  u := make(map[string]*Utilization)
+  for i, name := range serviceNames {
+    u[name] = &Utilization{CPU: series[2*i], Memory: series[2*i+1]}
+  }
+  return u, nil
+}
+
+// EC2 CPU for each of the instances, keyed by instance id. EC2 doesn't report
+// memory without the CloudWatch agent.
+func InstanceCPU(ec2Ids []string, window time.Duration, sess *session.Session) (map[string]*Series, error) {
+  queries := make([]query, 0, len(ec2Ids))
+  for _, id := range ec2Ids {
+    queries = append(queries, query{label: id, namespace: Ec2Namespace, metric: CPUUtilization,
+      dimensions: map[string]string{"InstanceId": id}})
+  }
+  series, err := fetch(queries, window, sess)
+  if err != nil { return nil, err }
+  cpu := make(map[string]*Series)
+  for i, id := range ec2Ids { cpu[id] = series[i] }
+  return cpu, nil
+}
+
+// Eg. "12.5%".
+func PercentString(s *Series) (string) {
+  if s.Empty() { return "-" }
+  return fmt.Sprintf("%.1f%%", s.Latest())
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+  "fmt"
+  "net/http"
+  "time"
+  "github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+  "github.com/gorilla/mux"
+  "github.com/Sirupsen/logrus"
+  "ecs-pilot/metrics"
+
+  // "awslib"
+  "github.com/jdrivas/awslib"
+)
+
+const WINDOW_PARAM = "window"
+
+type MetricsResponse struct {
+  Window int64                                    `json:"window" locationName:"window"` // Seconds
+  Period int64                                    `json:"period" locationName:"period"` // Seconds
+  Cluster *metrics.Utilization                    `json:"cluster" locationName:"cluster"`
+  Services map[string]*metrics.Utilization        `json:"services" locationName:"services"`
+  Instances map[string]*metrics.Series            `json:"instances" locationName:"instances"` // EC2 CPU by instance id.
+}
+
+// GET /metrics/{clusterName}?window=1h
+func MetricsController(w http.ResponseWriter, r *http.Request) {
+  vars := mux.Vars(r)
+  clusterName := vars[CLUSTER_NAME_VAR];
+  f := logrus.Fields{"controller": "MetricsController", "cluster": clusterName}
+
+  window := metrics.DefaultWindow
+  if ws := r.URL.Query().Get(WINDOW_PARAM); ws != "" {
+    d, err := time.ParseDuration(ws)
+    if err != nil || d <= 0 {
+      http.Error(w, fmt.Sprintf("Bad window \"%s\", expected a duration like 1h or 30m.", ws), http.StatusBadRequest)
+      return
+    }
+    window = d
+  }
+  f["window"] = window.String()
+
+  sess, err := getAWSSession(r)
+  if err != nil {
+    log.Error(f, "Fail to find appropriate AWS Session", err)
+    http.Error(w, fmt.Sprintf("Failed to find appropriate AWS Session: %s", err), http.StatusFailedDependency )
+    return
+  }
+
+  mr := MetricsResponse{Window: int64(window.Seconds()), Period: metrics.Period(window)}
+  mr.Cluster, err = metrics.ClusterUtilization(clusterName, window, sess)
+  if err != nil {
+    log.Error(f, "Failed to obtain cluster metrics from AWS.", err)
+    http.Error(w, fmt.Sprintf("Failed to obtain metrics from AWS: %s", err), http.StatusFailedDependency)
+    return
+  }
+
+  services, _, err := awslib.DescribeServices(clusterName, sess)
+  if err != nil {
+    log.Error(f, "Failed to obtain services from AWS.", err)
+    http.Error(w, fmt.Sprintf("Failed to obtain services from AWS: %s", err), http.StatusFailedDependency)
+    return
+  }
+  serviceNames := make([]string, 0, len(services))
+  for _, s := range services { serviceNames = append(serviceNames, *s.ServiceName) }
+  mr.Services, err = metrics.ServiceUtilization(clusterName, serviceNames, window, sess)
+  if err != nil {
+    log.Error(f, "Failed to obtain service metrics from AWS.", err)
+    http.Error(w, fmt.Sprintf("Failed to obtain metrics from AWS: %s", err), http.StatusFailedDependency)
+    return
+  }
+
+  ciMap, _, err := awslib.GetContainerMaps(clusterName, sess)
+  if err != nil {
+    log.Error(f, "Failed to obtain Container Instance Descriptions from AWS.", err)
+    http.Error(w, fmt.Sprintf("Failed to obtain instances from AWS: %s", err), http.StatusFailedDependency)
+    return
+  }
+  ec2Ids := make([]string, 0, len(ciMap))
+  for _, ci := range ciMap {
+    if ci.Instance != nil { ec2Ids = append(ec2Ids, *ci.Instance.Ec2InstanceId) }
+  }
+  mr.Instances, err = metrics.InstanceCPU(ec2Ids, window, sess)
+  if err != nil {
+    log.Error(f, "Failed to obtain instance metrics from AWS.", err)
+    http.Error(w, fmt.Sprintf("Failed to obtain metrics from AWS: %s", err), http.StatusFailedDependency)
+    return
+  }
+  f["numberOfServices"] = len(mr.Services)
+  f["numberOfInstances"] = len(mr.Instances)
+  log.Debug(f, "Got metrics from AWS.")
+
+  response, err  := jsonutil.BuildJSON(mr)
+  if err != nil {
+    log.Error(f, "Failed to marshall JSON on metrics.", err)
+    http.Error(w, "Failed to marshall JSON for response.", http.StatusInternalServerError)
+    return
+  }
+
+  _, err = w.Write(response)
+  if err != nil {
+    log.Error(f, "Failed to write JSON response", err)
+  } else {
+    log.Debug(f, "Sent repsonse.")
+  }
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "github.com/Sirupsen/logrus"
+  "ecs-pilot/doctor"
+)
+
+const PERMISSION_LEVEL_KEY = "for"
+
+type PermissionsReport struct {
+  Principal string `json:"principal"`
+  Level string `json:"level"`
+  Results []doctor.Result `json:"results"`
+}
+
+// Checks the permissions of the role the request's JWT assumed, /permissions?for=deploy.
+func PermissionsController(w http.ResponseWriter, r *http.Request) {
+  f := logrus.Fields{"controller": "PermissionsController"}
+
+  sess, err := getAWSSession(r)
+  if err != nil {
+    log.Error(f, "Fail to find appropriate AWS Session", err)
+    http.Error(w, fmt.Sprintf("Failed to find appropriate AWS Session: %s", err), http.StatusFailedDependency )
+    return
+  }
+
+  level := r.URL.Query().Get(PERMISSION_LEVEL_KEY)
+  if level == "" { level = doctor.AdminLevel }
+  f["level"] = level
+  if _, err := doctor.FeaturesFor(level); err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+
+  principal, results, err := doctor.CheckPermissions(level, sess)
+  if err != nil {
+    log.Error(f, "Failed to check permissions", err)
+    http.Error(w, fmt.Sprintf("Failed to check permissions: %s", err), http.StatusFailedDependency)
+    return
+  }
+  f["principal"] = principal
+
+  reportJson, err := json.Marshal(PermissionsReport{Principal: principal, Level: level, Results: results})
+  if err != nil {
+    log.Error(f, "Failed to marshall JSON for permissions.", err)
+    http.Error(w, "Failed to marshall JSON for response.", http.StatusInternalServerError)
+    return
+  }
+
+  _, err = w.Write(reportJson)
+  if err != nil {
+    log.Error(f, "Failed to write JSON response", err)
+  } else {
+    log.Debug(f, "Sent response.")
+  }
+}
@@ -68,6 +68,8 @@ func serve(address string) (err error) {
   r.Handle(fmt.Sprintf("/instances/{%s}", CLUSTER_NAME_VAR), ApiAccess(InstancesController, baseSession, true));
   r.Handle(fmt.Sprintf("/tasks/{%s}", CLUSTER_NAME_VAR), ApiAccess(TasksController, baseSession, true));
   r.Handle("/security_groups", ApiAccess(SecurityGroupsController, baseSession, true));
+  r.Handle(fmt.Sprintf("/metrics/{%s}", CLUSTER_NAME_VAR), ApiAccess(MetricsController, baseSession, true));
+  r.Handle("/permissions", ApiAccess(PermissionsController, baseSession, true));
 
   // r.HandleFunc("/sessionId", ApiAccess(SessionIdController, baseSession, true));
   // r.HandleFunc("/clusters", ApiAccess(ClusterController, baseSession, true));